@@ -21,6 +21,17 @@ const (
 	// AccountTypeTeacher => teacher
 	AccountTypeTeacher = 2
 
+	// role, an account may hold several
+	RoleAdmin          = 1  // 系统管理员
+	RoleDeanOffice     = 2  // 教务处
+	RoleHeadTeacher    = 4  // 班主任
+	RoleSubjectTeacher = 8  // 任课教师
+	RoleStudent        = 16 // 学生
+
+	// account status
+	AccountStatusActive   = 1 // 正常
+	AccountStatusDisabled = 2 // 停用
+
 	// status code
 	StatusValid    = 1 // Imply that this meta is available
 	StatusArchived = 2 // Imply that this meta will be no longer in use, just exist for reference
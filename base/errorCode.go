@@ -5,6 +5,10 @@ package base
 const (
 	ErrInvalidInput     = 400
 	ErrInvalidParameter = 400
+	ErrPermission       = 401 // not allowed to view the resource
 	ErrPartialFailed    = 403
+	ErrClosed           = 410 // questionnaire stopped
+	ErrNotOpen          = 425 // questionnaire not started
+	ErrMustChange       = 428 // password must be changed before anything else
 	ErrInternal         = 500
 )
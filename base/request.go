@@ -1,27 +1,96 @@
 package base
 
 import (
-	"crypto/md5"
-	"fmt"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
+// SignWindow seconds a signed request stays valid
+const SignWindow = 30
+
 type BaseRequest struct {
-	Token     string      `json:"token"`
-	Timestamp int64       `json:"timestamp"`
-	Check     string      `json:"check"`
-	Data      interface{} `json:"data"`
+	Token     string          `json:"token"`
+	Timestamp int64           `json:"timestamp"`
+	Nonce     string          `json:"nonce"`
+	Check     string          `json:"check"` // hex encoded HMAC-SHA256, see Sign
+	Data      json.RawMessage `json:"data"`
+}
+
+// InWindow check to see if the timestamp is within SignWindow of now
+func (b *BaseRequest) InWindow(now time.Time) bool {
+	diff := now.Unix() - b.Timestamp
+	return diff <= SignWindow && diff >= -SignWindow
 }
 
-func (b *BaseRequest) IsValid() bool {
-	md5str := fmt.Sprintf("%x", md5.Sum([]byte(b.Token+fmt.Sprintf("%d", b.Timestamp))))
-	if b.Check != md5str {
+// IsValid verify the signature of the request, body is the data field of
+// post request or the canonical query of get request
+func (b *BaseRequest) IsValid(secret, method, path string, body []byte) bool {
+	if secret == "" || b.Nonce == "" || len(b.Nonce) > 64 {
 		return false
 	}
 
-	if b.Timestamp+30 < time.Now().Unix() {
+	expected := Sign(secret, method, path, b.Timestamp, b.Nonce, body)
+	return hmac.Equal([]byte(expected), []byte(b.Check))
+}
+
+// Sign compute the signature over method, path, timestamp, nonce and body
+func Sign(secret, method, path string, timestamp int64, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + path + "\n" + strconv.FormatInt(timestamp, 10) + "\n" + nonce + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CanonicalQuery query of get request without the signature, keys are sorted
+func CanonicalQuery(q url.Values) []byte {
+	tmp := url.Values{}
+	for k, v := range q {
+		if k == "check" {
+			continue
+		}
+		tmp[k] = v
+	}
+	return []byte(tmp.Encode())
+}
+
+// NonceCache remember nonce seen within SignWindow to reject replayed request
+type NonceCache struct {
+	mutex     sync.Mutex
+	seen      map[string]int64 // nonce => expire time
+	lastPurge int64
+}
+
+// Add return false if nonce is seen before
+func (nc *NonceCache) Add(nonce string, now time.Time) bool {
+	nc.mutex.Lock()
+	defer nc.mutex.Unlock()
+
+	if nc.seen == nil {
+		nc.seen = make(map[string]int64)
+	}
+
+	// drop expired ones, a request older than the window is rejected anyway
+	if nc.lastPurge != now.Unix() {
+		nc.lastPurge = now.Unix()
+		for k, v := range nc.seen {
+			if v < now.Unix() {
+				delete(nc.seen, k)
+			}
+		}
+	}
+
+	if _, ok := nc.seen[nonce]; ok {
 		return false
 	}
+	nc.seen[nonce] = now.Unix() + 2*SignWindow
 	return true
 }
 
@@ -32,3 +101,25 @@ type DelList struct {
 type SingleID struct {
 	ID int
 }
+
+// ClientIP address of the client. X-Forwarded-For is set by the client as well,
+// only the addresses appended by the trusted reverse proxies are believed, so
+// the client is the one right before them. remote address is used if trusted
+// is 0
+func ClientIP(remoteAddr, forwarded string, trusted int) string {
+	ip, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		ip = remoteAddr
+	}
+	if trusted <= 0 || forwarded == "" {
+		return ip
+	}
+
+	// the nearest proxy is the remote address
+	chain := append(strings.Split(forwarded, ","), ip)
+	i := len(chain) - 1 - trusted
+	if i < 0 {
+		i = 0
+	}
+	return strings.TrimSpace(chain[i])
+}
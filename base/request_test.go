@@ -0,0 +1,88 @@
+package base
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestBaseRequest_IsValid(t *testing.T) {
+	now := time.Now()
+	body := json.RawMessage(`{"id":1}`)
+	req := BaseRequest{Token: "token", Timestamp: now.Unix(), Nonce: "nonce", Data: body}
+	req.Check = Sign("secret", "POST", "/api/v1/dean/class/info", req.Timestamp, req.Nonce, body)
+
+	if !req.InWindow(now) || !req.IsValid("secret", "POST", "/api/v1/dean/class/info", body) {
+		t.Fatal("valid request rejected")
+	}
+
+	if req.IsValid("other", "POST", "/api/v1/dean/class/info", body) {
+		t.Fatal("wrong secret accepted")
+	}
+
+	if req.IsValid("secret", "POST", "/api/v1/dean/class/delete", body) {
+		t.Fatal("wrong path accepted")
+	}
+
+	if req.IsValid("secret", "POST", "/api/v1/dean/class/info", json.RawMessage(`{"id":2}`)) {
+		t.Fatal("tampered body accepted")
+	}
+
+	if req.InWindow(now.Add((SignWindow + 1) * time.Second)) {
+		t.Fatal("expired request accepted")
+	}
+
+	req.Nonce = ""
+	if req.IsValid("secret", "POST", "/api/v1/dean/class/info", body) {
+		t.Fatal("request without nonce accepted")
+	}
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	q := url.Values{"token": {"t"}, "check": {"c"}, "id": {"1"}}
+	if string(CanonicalQuery(q)) != "id=1&token=t" {
+		t.Fatal("unexpected query", string(CanonicalQuery(q)))
+	}
+}
+
+func TestNonceCache_Add(t *testing.T) {
+	nc := NonceCache{}
+	now := time.Now()
+
+	if !nc.Add("a", now) || nc.Add("a", now) {
+		t.Fatal("replayed nonce accepted")
+	}
+
+	if !nc.Add("b", now) {
+		t.Fatal("new nonce rejected")
+	}
+
+	// forgotten after the window
+	if !nc.Add("a", now.Add(3*SignWindow*time.Second)) {
+		t.Fatal("expired nonce kept")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	in := []struct {
+		remote, forwarded string
+		trusted           int
+		ip                string
+	}{
+		{"10.0.0.1:2008", "", 0, "10.0.0.1"},
+		{"10.0.0.1:2008", "1.1.1.1", 0, "10.0.0.1"},
+		{"10.0.0.1:2008", "1.1.1.1", 1, "1.1.1.1"},
+		// address forged by the client is skipped
+		{"10.0.0.1:2008", "6.6.6.6, 1.1.1.1", 1, "1.1.1.1"},
+		{"10.0.0.2:2008", "6.6.6.6, 1.1.1.1, 10.0.0.1", 2, "1.1.1.1"},
+		{"10.0.0.1:2008", "1.1.1.1", 3, "1.1.1.1"},
+		{"10.0.0.1:2008", "", 1, "10.0.0.1"},
+	}
+
+	for k, v := range in {
+		if ip := ClientIP(v.remote, v.forwarded, v.trusted); ip != v.ip {
+			t.Fatal(k, "ip mismatch", ip)
+		}
+	}
+}
@@ -1,3 +1,8 @@
 package base
 
-const ()
+import "time"
+
+// ParseDateTime parse text of DateTimeFormat in local time zone
+func ParseDateTime(s string) (time.Time, error) {
+	return time.ParseInLocation(DateTimeFormat, s, time.Local)
+}
@@ -1,6 +1,11 @@
 package controllers
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/arong/dean/base"
+	"github.com/astaxie/beego/context"
+)
 
 const (
 	defaultResp     = `{"code":-1,"msg":"internal error","data":null}`
@@ -9,6 +14,19 @@ const (
 	msgSuccess      = "success"
 )
 
+// trustedProxies count of reverse proxies in front of the server
+var trustedProxies int
+
+// SetTrustedProxies set count of reverse proxies whose X-Forwarded-For is believed
+func SetTrustedProxies(n int) {
+	trustedProxies = n
+}
+
+// clientIP address of the client, see base.ClientIP
+func clientIP(ctx *context.Context) string {
+	return base.ClientIP(ctx.Request.RemoteAddr, ctx.Input.Header("X-Forwarded-For"), trustedProxies)
+}
+
 type BaseResponse struct {
 	Code int         `json:"code"`
 	Msg  string      `json:"msg"`
@@ -23,7 +23,7 @@ type AuthController struct {
 func (l *AuthController) Login() {
 	resp := &BaseResponse{Code: -1}
 	req := models.LoginRequest{}
-	token := ""
+	var ret *models.LoginResult
 
 	err := json.Unmarshal([]byte(l.Ctx.Input.RequestBody), &req)
 	if err != nil {
@@ -39,7 +39,8 @@ func (l *AuthController) Login() {
 		goto Out
 	}
 
-	token, err = models.Ac.Login(&req)
+	req.IP = clientIP(l.Ctx)
+	ret, err = models.Ac.Login(&req)
 	if err != nil {
 		logs.Debug("[AuthController::Login] login failed", err)
 		resp.Msg = err.Error()
@@ -48,10 +49,8 @@ func (l *AuthController) Login() {
 
 	resp.Code = 0
 	resp.Msg = msgSuccess
-	resp.Data = struct {
-		Token string `json:"token"`
-	}{Token: token}
-	logs.Info("[AuthController::Login] login success", req.LoginName, resp)
+	resp.Data = ret
+	logs.Info("[AuthController::Login] login success", req.LoginName)
 
 Out:
 	l.Data["json"] = resp
@@ -90,6 +89,7 @@ func (l *AuthController) Update() {
 		}
 		req.LoginName = loginInfo.LoginName
 		req.UserType = loginInfo.UserType
+		req.CurrentToken = loginInfo.CurrentToken
 	}
 
 	err = models.Ac.Update(&req)
@@ -122,19 +122,6 @@ func (l *AuthController) Reset() {
 		goto Out
 	}
 
-	{
-		l, ok := l.Ctx.Input.GetData(base.Private).(models.LoginInfo)
-		if !ok {
-			resp.Code = base.ErrInternal
-			goto Out
-		}
-		if l.UserType != base.AccountTypeTeacher {
-			resp.Code = base.ErrInvalidParameter
-			resp.Msg = "permission denied"
-			goto Out
-		}
-	}
-
 	if len(req.Password) != sha256.BlockSize {
 		resp.Code = base.ErrInvalidParameter
 		goto Out
@@ -155,30 +142,193 @@ Out:
 	l.ServeJSON()
 }
 
+type ResetStudentReq struct {
+	StudentID int64 `json:"student_id"`
+}
+
+// @Title ResetStudent
+// @Description reset password of one student to the default one, it must be changed on next login
+// @Param	body		body 	controllers.ResetStudentReq	true		"the student id"
+// @Success 200 {object} models.BaseResponse
+// @router /reset/student [post]
+func (l *AuthController) ResetStudent() {
+	resp := &BaseResponse{Code: -1}
+	req := ResetStudentReq{}
+
+	err := json.Unmarshal([]byte(l.Ctx.Input.RequestBody), &req)
+	if err != nil || req.StudentID <= 0 {
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = "invalid request"
+		goto Out
+	}
+
+	err = models.Ac.ResetStudentPassword(req.StudentID)
+	if err != nil {
+		logs.Debug("[AuthController::ResetStudent] ResetStudentPassword failed", err)
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	resp.Code = 0
+	resp.Msg = msgSuccess
+	logs.Info("[AuthController::ResetStudent] password reset", "studentID", req.StudentID)
+
+Out:
+	l.Data["json"] = resp
+	l.ServeJSON()
+}
+
 // @Title Logout
-// @Description Logs user into the system
-// @Success 200 {string} login success
+// @Description Logs user out of the system
+// @Success 200 {string} logout success
 // @Failure 403 user not exist
 // @router /logout [post]
 func (l *AuthController) Logout() {
 	resp := &BaseResponse{Code: -1}
-	req := base.BaseRequest{}
+	var err error
+
+	loginInfo, ok := l.Ctx.Input.GetData(base.Private).(models.LoginInfo)
+	if !ok {
+		logs.Warn("[AuthController::Logout] bug found")
+		resp.Code = base.ErrInternal
+		goto Out
+	}
+
+	err = models.Ac.Logout(loginInfo.CurrentToken)
+	if err != nil {
+		logs.Debug("[AuthController::Logout] logout failed", err)
+		resp.Msg = err.Error()
+		goto Out
+	}
+	resp.Code = 0
+	resp.Msg = msgSuccess
+Out:
+	l.Data["json"] = resp
+	l.ServeJSON()
+}
+
+// @Title Revoke
+// @Description force logout every session of an account
+// @Param	body		body 	models.LoginKey	true		"account to sign out"
+// @Success 200 {object} models.BaseResponse
+// @Failure 403 permission denied
+// @router /revoke [post]
+func (l *AuthController) Revoke() {
+	resp := &BaseResponse{Code: -1}
+	req := models.LoginKey{}
+	count := 0
 
 	err := json.Unmarshal([]byte(l.Ctx.Input.RequestBody), &req)
 	if err != nil {
-		logs.Debug("[UserController::Login] invalid data")
-		resp.Msg = "invalid data"
+		resp.Code = base.ErrInvalidInput
+		resp.Msg = "invalid request"
 		goto Out
 	}
 
-	err = models.Ac.Logout(req.Token)
+	count, err = models.Ac.ForceLogout(req)
 	if err != nil {
-		logs.Debug("[UserController::Login] login failed", err)
+		logs.Debug("[AuthController::Revoke] ForceLogout failed", err)
+		resp.Code = base.ErrInvalidParameter
 		resp.Msg = err.Error()
 		goto Out
 	}
+
 	resp.Code = 0
 	resp.Msg = msgSuccess
+	resp.Data = struct {
+		Count int `json:"count"`
+	}{Count: count}
+	logs.Info("[AuthController::Revoke] account signed out", "loginName", req.LoginName, "count", count)
+
+Out:
+	l.Data["json"] = resp
+	l.ServeJSON()
+}
+
+// @Title Role
+// @Description grant roles to a teacher
+// @Param	body		body 	models.RoleRequest	true		"account and role names"
+// @Success 200 {object} models.BaseResponse
+// @Failure 403 permission denied
+// @router /role [post]
+func (l *AuthController) Role() {
+	resp := &BaseResponse{Code: -1}
+	req := models.RoleRequest{}
+
+	err := json.Unmarshal([]byte(l.Ctx.Input.RequestBody), &req)
+	if err != nil {
+		resp.Code = base.ErrInvalidInput
+		resp.Msg = "invalid request"
+		goto Out
+	}
+
+	err = models.Ac.SetRole(&req)
+	if err != nil {
+		logs.Debug("[AuthController::Role] SetRole failed", err)
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	resp.Code = 0
+	resp.Msg = msgSuccess
+
+Out:
+	l.Data["json"] = resp
+	l.ServeJSON()
+}
+
+// @Title Lockouts
+// @Description accounts and ip locked for failed login, with failure history
+// @Param	all		query 	bool	false		"include the ones failed recently but not locked"
+// @Success 200 {object} models.LockRecordList
+// @router /lockout/list [get]
+func (l *AuthController) Lockouts() {
+	resp := &BaseResponse{Code: -1}
+
+	all, err := l.GetBool("all", false)
+	if err != nil {
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = msgInvalidParam
+		goto Out
+	}
+
+	resp.Code = 0
+	resp.Msg = msgSuccess
+	resp.Data = models.Ac.Lockouts(all)
+
+Out:
+	l.Data["json"] = resp
+	l.ServeJSON()
+}
+
+// @Title Unlock
+// @Description unlock an account or an ip
+// @Param	body		body 	models.UnlockRequest	true		"account or ip"
+// @Success 200 {object} models.BaseResponse
+// @router /lockout/unlock [post]
+func (l *AuthController) Unlock() {
+	resp := &BaseResponse{Code: -1}
+	req := models.UnlockRequest{}
+
+	err := json.Unmarshal([]byte(l.Ctx.Input.RequestBody), &req)
+	if err != nil {
+		resp.Code = base.ErrInvalidInput
+		resp.Msg = "invalid request"
+		goto Out
+	}
+
+	err = models.Ac.Unlock(&req)
+	if err != nil {
+		logs.Debug("[AuthController::Unlock] Unlock failed", err)
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	resp.Code = 0
+	resp.Msg = msgSuccess
+
 Out:
 	l.Data["json"] = resp
 	l.ServeJSON()
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/arong/dean/base"
+	"github.com/arong/dean/models"
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/logs"
+)
+
+// MasterController views of head teacher, scoped to classes the teacher is master of
+type MasterController struct {
+	beego.Controller
+}
+
+// masterClass get class in query, only the head teacher of the class is allowed,
+// resp is filled on failure
+func (m *MasterController) masterClass(resp *BaseResponse) *models.Class {
+	loginInfo, ok := m.Ctx.Input.GetData(base.Private).(models.LoginInfo)
+	if !ok || loginInfo.UserType != base.AccountTypeTeacher {
+		resp.Code = base.ErrPermission
+		resp.Msg = "permission denied"
+		return nil
+	}
+
+	id, err := strconv.Atoi(m.Ctx.Input.Query("class_id"))
+	if err != nil || id <= 0 {
+		logs.Debug("[MasterController::masterClass] invalid class id")
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = msgInvalidParam
+		return nil
+	}
+
+	c, err := models.Cm.GetMasterClass(id, loginInfo.ID)
+	if err != nil {
+		logs.Debug("[MasterController::masterClass] GetMasterClass failed", "err", err)
+		resp.Code = base.ErrPermission
+		resp.Msg = err.Error()
+		return nil
+	}
+	return c
+}
+
+// @Title Classes
+// @Description classes the teacher is head teacher of
+// @Success 200 {object} models.ClassList
+// @router /class/list [get]
+func (m *MasterController) Classes() {
+	resp := BaseResponse{Code: -1}
+
+	loginInfo, ok := m.Ctx.Input.GetData(base.Private).(models.LoginInfo)
+	if !ok || loginInfo.UserType != base.AccountTypeTeacher {
+		resp.Code = base.ErrPermission
+		resp.Msg = "permission denied"
+		goto Out
+	}
+
+	resp.Code = 0
+	resp.Msg = msgSuccess
+	resp.Data = models.Cm.MasterClasses(loginInfo.ID)
+Out:
+	m.Data["json"] = resp
+	m.ServeJSON()
+}
+
+// @Title Students
+// @Description students of the class
+// @Param	class_id		query 	int	true		"the class id"
+// @Success 200 {object} models.StudentInfo
+// @router /class/students [get]
+func (m *MasterController) Students() {
+	resp := BaseResponse{Code: -1}
+
+	c := m.masterClass(&resp)
+	if c == nil {
+		goto Out
+	}
+
+	resp.Code = 0
+	resp.Msg = msgSuccess
+	resp.Data = models.Um.GetClassStudents(c.ID)
+Out:
+	m.Data["json"] = resp
+	m.ServeJSON()
+}
+
+// @Title Teachers
+// @Description teachers of the class
+// @Param	class_id		query 	int	true		"the class id"
+// @Success 200 {object} models.InstructorList
+// @router /class/teachers [get]
+func (m *MasterController) Teachers() {
+	resp := BaseResponse{Code: -1}
+
+	c := m.masterClass(&resp)
+	if c == nil {
+		goto Out
+	}
+
+	resp.Code = 0
+	resp.Msg = msgSuccess
+	resp.Data = c.TeacherList
+Out:
+	m.Data["json"] = resp
+	m.ServeJSON()
+}
+
+// @Title Scores
+// @Description current exam score of the class
+// @Param	class_id		query 	int	true		"the class id"
+// @Success 200 {object} models.StudentScoreList
+// @router /class/scores [get]
+func (m *MasterController) Scores() {
+	resp := BaseResponse{Code: -1}
+	var err error
+	var ret models.StudentScoreList
+
+	c := m.masterClass(&resp)
+	if c == nil {
+		goto Out
+	}
+
+	ret, err = models.SSM.GetClassScore(c.ID)
+	if err != nil {
+		logs.Info("[MasterController::Scores] GetClassScore failed", "err", err)
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	resp.Code = 0
+	resp.Msg = msgSuccess
+	resp.Data = ret
+Out:
+	m.Data["json"] = resp
+	m.ServeJSON()
+}
+
+// @Title Response
+// @Description response rate of the class in questionnaire
+// @Param	class_id		query 	int	true		"the class id"
+// @Param	questionnaire_id		query 	int	true		"the questionnaire id"
+// @Success 200 {object} models.ResponseRate
+// @router /class/response [get]
+func (m *MasterController) Response() {
+	resp := BaseResponse{Code: -1}
+	var qid int
+	var err error
+	var ret *models.ResponseRate
+
+	c := m.masterClass(&resp)
+	if c == nil {
+		goto Out
+	}
+
+	qid, err = strconv.Atoi(m.Ctx.Input.Query("questionnaire_id"))
+	if err != nil || qid <= 0 {
+		logs.Debug("[MasterController::Response] invalid questionnaire id")
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = msgInvalidParam
+		goto Out
+	}
+
+	ret, err = models.QuestionnaireManager.ResponseRate(qid, c.ID)
+	if err != nil {
+		logs.Info("[MasterController::Response] ResponseRate failed", "err", err)
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	resp.Code = 0
+	resp.Msg = msgSuccess
+	resp.Data = ret
+Out:
+	m.Data["json"] = resp
+	m.ServeJSON()
+}
@@ -155,6 +155,123 @@ Out:
 	q.ServeJSON()
 }
 
+// @Title Publish
+// @Description publish questionnaire, it will be open at start time
+// @Param	body		body 	base.SingleID	true		"questionnaire id"
+// @Success 200 {string} 0
+// @Failure 403 body is empty
+// @router /publish [post]
+func (q *QuestionnaireController) Publish() {
+	var request base.SingleID
+	resp := BaseResponse{}
+
+	err := json.Unmarshal(q.Ctx.Input.RequestBody, &request)
+	if err != nil {
+		logs.Debug("[QuestionnaireController::Publish] invalid json", "err", err)
+		resp.Msg = msgInvalidJSON
+		resp.Code = base.ErrInvalidInput
+		goto Out
+	}
+
+	if request.ID <= 0 {
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = "invalid id"
+		goto Out
+	}
+
+	err = models.QuestionnaireManager.Publish(request.ID)
+	if err != nil {
+		logs.Info("[QuestionnaireController::Publish] Publish failed", "err", err)
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	resp.Code = 0
+	resp.Msg = msgSuccess
+Out:
+	q.Data["json"] = resp
+	q.ServeJSON()
+}
+
+// @Title Withdraw
+// @Description withdraw published questionnaire
+// @Param	body		body 	base.SingleID	true		"questionnaire id"
+// @Success 200 {string} 0
+// @Failure 403 body is empty
+// @router /withdraw [post]
+func (q *QuestionnaireController) Withdraw() {
+	var request base.SingleID
+	resp := BaseResponse{}
+
+	err := json.Unmarshal(q.Ctx.Input.RequestBody, &request)
+	if err != nil {
+		logs.Debug("[QuestionnaireController::Withdraw] invalid json", "err", err)
+		resp.Msg = msgInvalidJSON
+		resp.Code = base.ErrInvalidInput
+		goto Out
+	}
+
+	if request.ID <= 0 {
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = "invalid id"
+		goto Out
+	}
+
+	err = models.QuestionnaireManager.Withdraw(request.ID)
+	if err != nil {
+		logs.Info("[QuestionnaireController::Withdraw] Withdraw failed", "err", err)
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	resp.Code = 0
+	resp.Msg = msgSuccess
+Out:
+	q.Data["json"] = resp
+	q.ServeJSON()
+}
+
+// @Title Expire
+// @Description close questionnaire before stop time
+// @Param	body		body 	base.SingleID	true		"questionnaire id"
+// @Success 200 {string} 0
+// @Failure 403 body is empty
+// @router /expire [post]
+func (q *QuestionnaireController) Expire() {
+	var request base.SingleID
+	resp := BaseResponse{}
+
+	err := json.Unmarshal(q.Ctx.Input.RequestBody, &request)
+	if err != nil {
+		logs.Debug("[QuestionnaireController::Expire] invalid json", "err", err)
+		resp.Msg = msgInvalidJSON
+		resp.Code = base.ErrInvalidInput
+		goto Out
+	}
+
+	if request.ID <= 0 {
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = "invalid id"
+		goto Out
+	}
+
+	err = models.QuestionnaireManager.Expire(request.ID)
+	if err != nil {
+		logs.Info("[QuestionnaireController::Expire] Expire failed", "err", err)
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	resp.Code = 0
+	resp.Msg = msgSuccess
+Out:
+	q.Data["json"] = resp
+	q.ServeJSON()
+}
+
 // @Title Get
 // @Description find object which meet filter
 // @Success 200 {object} models.ScoreInfo
@@ -191,6 +308,9 @@ func (q *QuestionnaireController) Submit() {
 		p := q.Ctx.Input.GetData(base.Private)
 		if l, ok := p.(models.LoginInfo); ok {
 			if l.UserType != base.AccountTypeStudent {
+				logs.Debug("[QuestionnaireController::Submit] not a student", "loginName", l.LoginName)
+				resp.Code = base.ErrPermission
+				resp.Msg = "only student could submit"
 				goto Out
 			}
 			request.StudentID = l.ID
@@ -212,6 +332,14 @@ func (q *QuestionnaireController) Submit() {
 		goto Out
 	}
 
+	err = models.QuestionnaireManager.Submit(request)
+	if err != nil {
+		logs.Info("[QuestionnaireController::Submit] Submit failed", "err", err)
+		resp.Code = openErrCode(err, base.ErrInvalidParameter)
+		resp.Msg = err.Error()
+		goto Out
+	}
+
 	resp.Code = 0
 	resp.Msg = msgSuccess
 Out:
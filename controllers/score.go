@@ -1,11 +1,12 @@
 package controllers
 
 import (
-	"fmt"
 	"strconv"
 
+	"github.com/arong/dean/base"
 	"github.com/arong/dean/models"
 	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/logs"
 )
 
 // Operations about object
@@ -14,16 +15,18 @@ type ScoreController struct {
 }
 
 // @Title Get
-// @Description find object by objectid
-// @Param	objectId		path 	string	true		"the objectid you want to get"
-// @Success 200 {object} models.ScoreInfo
-// @Failure 403 :objectId is empty
+// @Description get evaluation report of teacher in questionnaire
+// @Param	teacherID		path 	string	true		"the teacher id"
+// @Param	questionnaire_id		query 	string	true		"the questionnaire id"
+// @Success 200 {object} models.TeacherReport
+// @Failure 403 :teacherID is empty
 // @router /:teacherID [get]
 func (s *ScoreController) Get() {
 	resp := BaseResponse{Code: -1}
 	var err error
 	var id int64
-	ret := &models.ScoreInfo{}
+	var qid int
+	ret := &models.TeacherReport{}
 	teacherID := s.Ctx.Input.Param(":teacherID")
 	if teacherID == "" {
 		resp.Msg = msgInvalidParam
@@ -34,8 +37,18 @@ func (s *ScoreController) Get() {
 		resp.Msg = msgInvalidParam
 		goto Out
 	}
-	fmt.Println("teacherID=", id)
+
+	qid, err = strconv.Atoi(s.Ctx.Input.Query("questionnaire_id"))
+	if err != nil || qid <= 0 {
+		logs.Debug("[ScoreController::Get] invalid questionnaire id")
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = msgInvalidParam
+		goto Out
+	}
+
+	ret, err = models.QuestionnaireManager.Report(qid, id)
 	if err != nil {
+		logs.Info("[ScoreController::Get] Report failed", "err", err)
 		resp.Msg = err.Error()
 		goto Out
 	}
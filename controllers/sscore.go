@@ -15,14 +15,20 @@ type StudentScoreController struct {
 }
 
 // @Title Add
-// @Description add new record
+// @Description add scores of a student, teachers add only the subjects they teach in the class
 // @Success 200 {object} base.BaseResponse
 // @router /add [post]
 func (u *StudentScoreController) Add() {
+	var err error
 	request := models.StudentScore{}
 	resp := base.BaseResponse{}
 
-	err := json.Unmarshal(u.Ctx.Input.RequestBody, &request)
+	teacherID, ok := u.instructor(&resp)
+	if !ok {
+		goto Out
+	}
+
+	err = json.Unmarshal(u.Ctx.Input.RequestBody, &request)
 	if err != nil {
 		logs.Debug("[StudentScoreController::Add] invalid input", "err", err)
 		resp.Code = base.ErrInvalidInput
@@ -37,10 +43,13 @@ func (u *StudentScoreController) Add() {
 		goto Out
 	}
 
-	err = models.SSM.AddRecord(request)
+	err = models.SSM.AddRecord(request, teacherID)
 	if err != nil {
 		logs.Debug("[StudentScoreController::Add] AddRecord failed", "err", err)
 		resp.Code = base.ErrInternal
+		if err == models.ErrNotInstructor {
+			resp.Code = base.ErrPermission
+		}
 		resp.Msg = err.Error()
 		goto Out
 	}
@@ -51,6 +60,282 @@ Out:
 	u.ServeJSON()
 }
 
+// instructor teacher the scores are restricted to, zero for admin and dean
+// office who enter scores of any class. resp is filled on failure
+func (u *StudentScoreController) instructor(resp *base.BaseResponse) (int64, bool) {
+	loginInfo, ok := u.Ctx.Input.GetData(base.Private).(models.LoginInfo)
+	if !ok || loginInfo.UserType != base.AccountTypeTeacher {
+		resp.Code = base.ErrPermission
+		resp.Msg = "permission denied"
+		return 0, false
+	}
+
+	if models.Ac.Roles(loginInfo)&(base.RoleAdmin|base.RoleDeanOffice) != 0 {
+		return 0, true
+	}
+	return loginInfo.ID, true
+}
+
+// @Title AddClass
+// @Description add scores of a subject in an exam for a class, nothing is saved if any row is rejected
+// @Param	body		body 	models.ClassScore	true		"scores of the class"
+// @Success 200 {object} models.ImportResult
+// @router /class [post]
+func (u *StudentScoreController) AddClass() {
+	request := models.ClassScore{}
+	resp := base.BaseResponse{}
+
+	teacherID, ok := u.instructor(&resp)
+	if !ok {
+		goto Out
+	}
+
+	if err := json.Unmarshal(u.Ctx.Input.RequestBody, &request); err != nil {
+		logs.Debug("[StudentScoreController::AddClass] invalid input", "err", err)
+		resp.Code = base.ErrInvalidInput
+		goto Out
+	}
+
+	u.addClassScore(&request, teacherID, &resp)
+Out:
+	u.Data["json"] = resp
+	u.ServeJSON()
+}
+
+// @Title ImportClass
+// @Description add scores of a class from a csv or xlsx sheet, nothing is saved if any row is rejected
+// @Param	class_id	formData 	int	true		"class id"
+// @Param	subject_id	formData 	int	true		"subject id"
+// @Param	term_id		formData 	int	true		"year*10 + term, e.g. 20191"
+// @Param	exam		formData 	int	true		"exam of the term"
+// @Param	file		formData 	file	true		"columns: 学号,分数"
+// @Success 200 {object} models.ImportResult
+// @router /class/import [post]
+func (u *StudentScoreController) ImportClass() {
+	request := models.ClassScore{}
+	resp := base.BaseResponse{}
+
+	teacherID, ok := u.instructor(&resp)
+	if !ok {
+		goto Out
+	}
+
+	request.ClassID, _ = u.GetInt("class_id")
+	request.SubjectID, _ = u.GetInt("subject_id")
+	request.TermID, _ = u.GetInt("term_id")
+	request.Exam, _ = u.GetInt("exam")
+
+	{
+		file, header, err := u.GetFile("file")
+		if err != nil {
+			logs.Debug("[StudentScoreController::ImportClass] file not found", "err", err)
+			resp.Code = base.ErrInvalidParameter
+			resp.Msg = msgInvalidParam
+			goto Out
+		}
+		defer file.Close()
+
+		request.Scores, err = models.ParseScoreSheet(header.Filename, file)
+		if err != nil {
+			logs.Debug("[StudentScoreController::ImportClass] ParseScoreSheet failed", "err", err)
+			resp.Code = base.ErrInvalidParameter
+			resp.Msg = err.Error()
+			goto Out
+		}
+	}
+
+	u.addClassScore(&request, teacherID, &resp)
+Out:
+	u.Data["json"] = resp
+	u.ServeJSON()
+}
+
+func (u *StudentScoreController) addClassScore(request *models.ClassScore, teacherID int64, resp *base.BaseResponse) {
+	ret, err := models.SSM.AddClassScore(request, teacherID)
+	if err != nil {
+		logs.Debug("[StudentScoreController::addClassScore] AddClassScore failed", "err", err)
+		resp.Code = base.ErrInvalidParameter
+		if err == models.ErrNotInstructor {
+			resp.Code = base.ErrPermission
+		}
+		resp.Msg = err.Error()
+		return
+	}
+
+	resp.Data = ret
+	if len(ret.Errors) != 0 {
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = "scores rejected"
+		return
+	}
+	resp.Msg = msgSuccess
+}
+
+// @Title Rank
+// @Description scores of an exam with class rank and grade rank, head teachers see their own classes only
+// @Param	body		body 	models.ScoreFilter	true		"class, grade or student, term and exam, subject"
+// @Success 200 {object} models.RankList
+// @router /rank [post]
+func (u *StudentScoreController) Rank() {
+	request := models.ScoreFilter{}
+	resp := base.BaseResponse{}
+	var ret models.RankList
+	var err error
+
+	loginInfo, ok := u.Ctx.Input.GetData(base.Private).(models.LoginInfo)
+	if !ok || loginInfo.UserType != base.AccountTypeTeacher {
+		resp.Code = base.ErrPermission
+		resp.Msg = "permission denied"
+		goto Out
+	}
+
+	err = json.Unmarshal(u.Ctx.Input.RequestBody, &request)
+	if err != nil {
+		logs.Debug("[StudentScoreController::Rank] invalid input", "err", err)
+		resp.Code = base.ErrInvalidInput
+		goto Out
+	}
+
+	if models.Ac.Roles(loginInfo)&(base.RoleAdmin|base.RoleDeanOffice) == 0 {
+		// head teacher publishes results of the own class
+		_, err = models.Cm.GetMasterClass(request.ClassID, loginInfo.ID)
+		if err != nil {
+			logs.Debug("[StudentScoreController::Rank] not head teacher of the class", "classID", request.ClassID)
+			resp.Code = base.ErrPermission
+			resp.Msg = "permission denied"
+			goto Out
+		}
+	}
+
+	ret, err = models.SSM.Rank(request)
+	if err != nil {
+		logs.Debug("[StudentScoreController::Rank] Rank failed", "err", err)
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	resp.Msg = msgSuccess
+	resp.Data = ret
+Out:
+	u.Data["json"] = resp
+	u.ServeJSON()
+}
+
+// @Title Statistics
+// @Description statistics per subject of an exam for a class, a grade or classes of a teacher.
+// head teachers see their own classes, subject teachers the subjects they teach
+// @Param	body		body 	models.StatFilter	true		"scope and exam"
+// @Success 200 {object} models.SubjectStatList
+// @router /stat [post]
+func (u *StudentScoreController) Statistics() {
+	request := models.StatFilter{}
+	resp := base.BaseResponse{}
+	var ret models.SubjectStatList
+	var err error
+
+	loginInfo, ok := u.Ctx.Input.GetData(base.Private).(models.LoginInfo)
+	if !ok || loginInfo.UserType != base.AccountTypeTeacher {
+		resp.Code = base.ErrPermission
+		resp.Msg = "permission denied"
+		goto Out
+	}
+
+	err = json.Unmarshal(u.Ctx.Input.RequestBody, &request)
+	if err != nil {
+		logs.Debug("[StudentScoreController::Statistics] invalid input", "err", err)
+		resp.Code = base.ErrInvalidInput
+		goto Out
+	}
+
+	if models.Ac.Roles(loginInfo)&(base.RoleAdmin|base.RoleDeanOffice) == 0 {
+		_, err = models.Cm.GetMasterClass(request.ClassID, loginInfo.ID)
+		if err != nil {
+			if request.ClassID != 0 || request.Grade != 0 {
+				logs.Debug("[StudentScoreController::Statistics] scope not allowed", "classID", request.ClassID, "grade", request.Grade)
+				resp.Code = base.ErrPermission
+				resp.Msg = "permission denied"
+				goto Out
+			}
+			request.TeacherID = loginInfo.ID
+		}
+	}
+
+	ret, err = models.SSM.Statistics(request)
+	if err != nil {
+		logs.Debug("[StudentScoreController::Statistics] Statistics failed", "err", err)
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	resp.Msg = msgSuccess
+	resp.Data = ret
+Out:
+	u.Data["json"] = resp
+	u.ServeJSON()
+}
+
+// @Title Trend
+// @Description score series of a student across exams with rank movement.
+// students see their own, head teachers students of their classes
+// @Param	student_id		query 	int	false		"student id, not used by students"
+// @Success 200 {object} models.ScoreTrend
+// @router /trend [get]
+func (u *StudentScoreController) Trend() {
+	resp := base.BaseResponse{}
+	var ret *models.ScoreTrend
+	var studentID int64
+	var err error
+
+	loginInfo, ok := u.Ctx.Input.GetData(base.Private).(models.LoginInfo)
+	if !ok {
+		resp.Code = base.ErrPermission
+		resp.Msg = "permission denied"
+		goto Out
+	}
+
+	if loginInfo.UserType == base.AccountTypeStudent {
+		studentID = loginInfo.ID
+	} else {
+		studentID, err = u.GetInt64("student_id")
+		if err != nil || studentID <= 0 {
+			logs.Debug("[StudentScoreController::Trend] invalid student id")
+			resp.Code = base.ErrInvalidParameter
+			resp.Msg = msgInvalidParam
+			goto Out
+		}
+
+		if models.Ac.Roles(loginInfo)&(base.RoleAdmin|base.RoleDeanOffice) == 0 {
+			// head teacher of the class the student is in
+			s, err := models.Um.GetUser(studentID)
+			if err == nil {
+				_, err = models.Cm.GetMasterClass(s.ClassID, loginInfo.ID)
+			}
+			if err != nil {
+				logs.Debug("[StudentScoreController::Trend] not head teacher of the student", "studentID", studentID)
+				resp.Code = base.ErrPermission
+				resp.Msg = "permission denied"
+				goto Out
+			}
+		}
+	}
+
+	ret, err = models.SSM.Trend(studentID)
+	if err != nil {
+		logs.Debug("[StudentScoreController::Trend] Trend failed", "err", err)
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	resp.Msg = msgSuccess
+	resp.Data = ret
+Out:
+	u.Data["json"] = resp
+	u.ServeJSON()
+}
+
 // @Title GetAll
 // @Description get all Users
 // @Success 200 {object} models.User
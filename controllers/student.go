@@ -48,6 +48,46 @@ Out:
 	u.ServeJSON()
 }
 
+// @Title Import
+// @Description import students from a csv or xlsx sheet, valid rows are saved in one transaction
+// @Param	file		formData 	file	true		"columns: 学号,姓名,性别,年级,班级,出生日期,地址,手机"
+// @Param	dry_run		query 	bool	false		"validate only"
+// @Success 200 {object} models.ImportResult
+// @Failure 403 invalid sheet
+// @router /import [post]
+func (u *StudentController) Import() {
+	resp := BaseResponse{Code: -1}
+	var rows []models.ImportRow
+	dryRun, _ := u.GetBool("dry_run")
+
+	file, header, err := u.GetFile("file")
+	if err != nil {
+		logs.Debug("[StudentController::Import] file not found", "err", err)
+		resp.Msg = msgInvalidParam
+		goto Out
+	}
+	defer file.Close()
+
+	rows, err = models.ParseStudentSheet(header.Filename, file)
+	if err != nil {
+		logs.Debug("[StudentController::Import] ParseStudentSheet failed", "err", err)
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	resp.Data, err = models.Um.Import(rows, dryRun)
+	if err != nil {
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	resp.Code = 0
+	resp.Msg = msgSuccess
+Out:
+	u.Data["json"] = resp
+	u.ServeJSON()
+}
+
 // @Title GetAll
 // @Description get all Users
 // @Param	grade		query 	string	true		"The grade of class"
@@ -111,30 +151,23 @@ Out:
 }
 
 // @Title Update
-// @Description update the user
-// @Param	uid		path 	string	true		"The uid you want to update"
-// @Param	body		body 	models.User	true		"body for user content"
+// @Description update the user, all fields are replaced
+// @Param	body		body 	models.StudentInfo	true		"body for user content"
 // @Success 200 {object} models.User
-// @Failure 403 :uid is not int
+// @Failure 403 student_id is empty
 // @router /update [post]
 func (u *StudentController) Update() {
 	resp := &BaseResponse{Code: -1}
-	tmp := u.GetString(":uid")
 	var user models.StudentInfo
-	uid, err := strconv.ParseInt(tmp, 10, 64)
+	err := json.Unmarshal(u.Ctx.Input.RequestBody, &user)
 	if err != nil {
-		logs.Debug("[StudentController::Update] parse uid failed")
-		goto Out
-	}
-
-	if uid == 0 {
-		logs.Debug("[StudentController::Update] invalid uid")
+		resp.Msg = msgInvalidJSON
 		goto Out
 	}
 
-	err = json.Unmarshal(u.Ctx.Input.RequestBody, &user)
-	if err != nil {
-		resp.Msg = msgInvalidJSON
+	if user.StudentID == 0 {
+		logs.Debug("[StudentController::Update] invalid student id")
+		resp.Msg = msgInvalidParam
 		goto Out
 	}
 
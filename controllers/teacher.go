@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"strconv"
 
+	"github.com/arong/dean/base"
 	"github.com/arong/dean/models"
 	"github.com/astaxie/beego"
 	"github.com/astaxie/beego/logs"
@@ -14,14 +15,20 @@ type TeacherController struct {
 	beego.Controller
 }
 
+type AddTeacherReq struct {
+	models.Teacher
+	LoginName string `json:"login_name"` // mobile or name if empty
+}
+
 // @Title Create
-// @Description create object
-// @Param	body		body 	models.Teacher	true		"The object content"
-// @Success 200 {string} models.Teacher.TeacherID
+// @Description create teacher and the login account, the initial password is returned only once
+// @Param	body		body 	controllers.AddTeacherReq	true		"The object content"
+// @Success 200 {object} models.Credential
 // @router /add [post]
 func (o *TeacherController) Add() {
 	var id int64
-	request := models.Teacher{}
+	var cred *models.Credential
+	request := AddTeacherReq{}
 	resp := BaseResponse{Code: -1}
 
 	err := json.Unmarshal(o.Ctx.Input.RequestBody, &request)
@@ -31,16 +38,32 @@ func (o *TeacherController) Add() {
 		goto Out
 	}
 
-	id, err = models.Tm.AddTeacher(&request)
+	if models.Ac.Reserved(request.LoginName) {
+		logs.Info("[TeacherController::Add] login name reserved", "loginName", request.LoginName)
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = models.ErrLoginNameReserved.Error()
+		goto Out
+	}
+
+	id, err = models.Tm.AddTeacher(&request.Teacher)
 	if err != nil {
 		resp.Msg = err.Error()
 		goto Out
 	}
+
+	cred, err = models.Ac.ProvisionTeacher(id, request.LoginName)
+	if err != nil {
+		// teacher is added, credential could be issued later by reissue
+		logs.Warn("[TeacherController::Add] ProvisionTeacher failed", "id", id, "err", err)
+		resp.Code = base.ErrPartialFailed
+		resp.Msg = "account not created: " + err.Error()
+		resp.Data = models.Credential{TeacherID: id}
+		goto Out
+	}
+
 	resp.Code = 0
 	resp.Msg = msgSuccess
-	resp.Data = struct {
-		ID int64 `json:"id"`
-	}{ID: id}
+	resp.Data = cred
 Out:
 	o.Data["json"] = resp
 	o.ServeJSON()
@@ -195,6 +218,7 @@ func (tc *TeacherController) Delete() {
 		resp.Data = ret
 		goto Out
 	}
+	models.Ac.DisableTeachers(request.IDList)
 
 	resp.Code = 0
 	resp.Msg = msgSuccess
@@ -207,3 +231,39 @@ Out:
 	tc.Data["json"] = resp
 	tc.ServeJSON()
 }
+
+type ReissueTeacherReq struct {
+	TeacherID int64 `json:"teacher_id"`
+}
+
+// @Title Reissue
+// @Description issue a new initial password to the teacher, the account is created if missing
+// @Param	body		body 	controllers.ReissueTeacherReq	true		"the teacher id"
+// @Success 200 {object} models.Credential
+// @router /reissue [post]
+func (tc *TeacherController) Reissue() {
+	request := ReissueTeacherReq{}
+	resp := &BaseResponse{Code: -1}
+	var cred *models.Credential
+
+	err := json.Unmarshal(tc.Ctx.Input.RequestBody, &request)
+	if err != nil || request.TeacherID <= 0 {
+		resp.Msg = msgInvalidParam
+		logs.Debug("[TeacherController::Reissue] invalid request", "err", err)
+		goto Out
+	}
+
+	cred, err = models.Ac.ReissueTeacher(request.TeacherID)
+	if err != nil {
+		logs.Info("[TeacherController::Reissue] ReissueTeacher failed", "err", err)
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	resp.Code = 0
+	resp.Msg = msgSuccess
+	resp.Data = cred
+Out:
+	tc.Data["json"] = resp
+	tc.ServeJSON()
+}
@@ -59,7 +59,8 @@ func (v *VoteController) GetQuestionnaire() {
 	ret, err = models.QuestionnaireManager.Generate(req)
 	if err != nil {
 		logs.Info("[VoteController::GetQuestionnaire] Generate failed", "err", err)
-		resp.Code = base.ErrInternal
+		resp.Code = openErrCode(err, base.ErrInternal)
+		resp.Msg = err.Error()
 		goto Out
 	}
 
@@ -71,19 +72,69 @@ Out:
 	v.ServeJSON()
 }
 
-// @Title Get
-// @Description find object by voteCode
-// @Param	voteCode		path	string	true		"the voteCode to verify access"
-// @Success 200 {object} models.ScoreInfo
+// @Title Submit
+// @Description submit answers of questionnaire
+// @Param	body		body 	models.QuestionnaireSubmit	true		"The answers"
+// @Success 200 {string} 0
 // @router /submit [post]
 func (v *VoteController) Submit() {
 	resp := BaseResponse{Code: -1}
-	var data *models.ClassResp
+	req := models.QuestionnaireSubmit{}
+	var err error
+
+	private := v.Ctx.Input.GetData(base.Private)
+	l, ok := private.(models.LoginInfo)
+	if !ok {
+		logs.Warn("[VoteController::Submit] bug found")
+		resp.Code = base.ErrInternal
+		goto Out
+	}
+
+	if l.UserType != base.AccountTypeStudent {
+		logs.Info("[VoteController::Submit] invalid account type")
+		resp.Code = base.ErrInvalidParameter
+		goto Out
+	}
+
+	err = json.Unmarshal(v.Ctx.Input.RequestBody, &req)
+	if err != nil {
+		logs.Info("[VoteController::Submit] invalid input data", "request", string(v.Ctx.Input.RequestBody))
+		resp.Code = base.ErrInvalidInput
+		goto Out
+	}
+
+	req.StudentID = l.ID
+	err = req.Check()
+	if err != nil {
+		logs.Debug("[VoteController::Submit] Check failed", "err", err)
+		resp.Code = base.ErrInvalidParameter
+		resp.Msg = err.Error()
+		goto Out
+	}
+
+	err = models.QuestionnaireManager.Submit(req)
+	if err != nil {
+		logs.Info("[VoteController::Submit] Submit failed", "err", err)
+		resp.Code = openErrCode(err, base.ErrInvalidParameter)
+		resp.Msg = err.Error()
+		goto Out
+	}
 
 	resp.Code = 0
 	resp.Msg = msgSuccess
-	resp.Data = data
-	//Out:
-	v.Data["json"] = resp
+Out:
+	v.Data["json"] = resp.Fill()
 	v.ServeJSON()
 }
+
+// openErrCode distinguish questionnaire not open and closed from other error
+func openErrCode(err error, code int) int {
+	switch err {
+	case models.ErrNotOpen:
+		return base.ErrNotOpen
+	case models.ErrClosed:
+		return base.ErrClosed
+	default:
+		return code
+	}
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"os/signal"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/arong/dean/base"
 	"github.com/arong/dean/controllers"
@@ -19,10 +21,15 @@ import (
 	"github.com/dgraph-io/badger"
 )
 
+// nonces of signed requests, to reject replay
+var nonceCache base.NonceCache
+
 var filterUser = func(ctx *context.Context) {
 	request := base.BaseRequest{}
 	var err error
+	var body []byte
 	msg := ""
+	now := time.Now()
 
 	if ctx.Input.IsPost() {
 		err := json.Unmarshal(ctx.Input.RequestBody, &request)
@@ -31,11 +38,7 @@ var filterUser = func(ctx *context.Context) {
 			msg = "bad request"
 			goto Out
 		}
-
-		if !request.IsValid() {
-			msg = "invalid request"
-			goto Out
-		}
+		body = request.Data
 	} else if ctx.Input.IsGet() {
 		v := ctx.Input.Query("token")
 		if v == "" {
@@ -57,6 +60,8 @@ var filterUser = func(ctx *context.Context) {
 			goto Out
 		}
 
+		request.Nonce = ctx.Input.Query("nonce")
+
 		v = ctx.Input.Query("check")
 		if v == "" {
 			logs.Info("[filterUser] check sum not found")
@@ -64,11 +69,12 @@ var filterUser = func(ctx *context.Context) {
 			goto Out
 		}
 		request.Check = v
+		body = base.CanonicalQuery(ctx.Request.URL.Query())
+	}
 
-		if !request.IsValid() {
-			msg = "invalid request"
-			goto Out
-		}
+	if !request.InWindow(now) {
+		msg = "request expired"
+		goto Out
 	}
 
 	// store login info to request context
@@ -80,25 +86,50 @@ var filterUser = func(ctx *context.Context) {
 				msg = "invalid token"
 				goto Out
 			}
-			// student only allowed to view certain page
-			if loginInfo.UserType == base.AccountTypeStudent &&
-				!strings.HasPrefix(path, "/api/v1/auth") &&
-				!strings.HasPrefix(path, "/api/v1/student") {
-				msg = "permission denied"
-				logs.Info("[filterUser] abnormal behavior found", "account", loginInfo, "url", ctx.Request.URL.Path)
+			if !request.IsValid(loginInfo.Secret, ctx.Input.Method(), path, body) {
+				logs.Info("[filterUser] invalid signature", "account", loginInfo, "url", path)
+				msg = "invalid request"
+				goto Out
+			}
+			if !nonceCache.Add(request.Token+request.Nonce, now) {
+				logs.Info("[filterUser] replayed request found", "account", loginInfo, "url", path)
+				msg = "invalid request"
 				goto Out
 			}
+			// initial password must be changed first
+			if loginInfo.MustChange && path != "/api/v1/auth/update" && path != "/api/v1/auth/logout" {
+				ctx.Output.JSON(controllers.BaseResponse{Code: base.ErrMustChange, Msg: "password must be changed"}, false, true)
+				return
+			}
 			ctx.Input.SetData(base.Private, loginInfo)
 		}
 	}
 
-	ctx.Input.RequestBody, _ = json.Marshal(request.Data)
+	if len(request.Data) == 0 {
+		request.Data = json.RawMessage("null")
+	}
+	ctx.Input.RequestBody = request.Data
 	ctx.Input.SetData(base.Data, request.Data)
 	return
 Out:
 	ctx.Output.JSON(controllers.BaseResponse{Code: -2, Msg: msg}, false, true)
 }
 
+// filterPermission check role of the account against the permission table,
+// it runs after routing so the action is known
+var filterPermission = func(ctx *context.Context) {
+	if ctx.Request.URL.Path == "/api/v1/auth/login" {
+		return
+	}
+
+	loginInfo, ok := ctx.Input.GetData(base.Private).(models.LoginInfo)
+	pattern, _ := ctx.Input.GetData("RouterPattern").(string)
+	if !ok || !models.Ac.Allowed(loginInfo, ctx.Input.Method(), pattern) {
+		logs.Info("[filterPermission] permission denied", "account", loginInfo, "url", ctx.Request.URL.Path)
+		ctx.Output.JSON(controllers.BaseResponse{Code: -2, Msg: "permission denied"}, false, true)
+	}
+}
+
 func signalHandler(db *badger.DB) {
 	c := make(chan os.Signal)
 	signal.Notify(c)
@@ -112,6 +143,55 @@ func signalHandler(db *badger.DB) {
 	}
 }
 
+func migrate(conf *models.DBConfig, args []string) error {
+	m, err := models.NewMigrator(conf)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	// target version, up to latest and down by one step by default
+	target := -1
+	if len(args) > 1 {
+		target, err = strconv.Atoi(args[1])
+		if err != nil || target < 0 {
+			return errors.New("invalid version: " + args[1])
+		}
+	}
+
+	switch cmd {
+	case "up":
+		if target < 0 {
+			target = m.Latest()
+		}
+		err = m.Up(target)
+	case "down":
+		if target < 0 {
+			var current int
+			current, err = m.Current()
+			if err != nil {
+				return err
+			}
+			target = current - 1
+		}
+		err = m.Down(target)
+	case "status":
+	default:
+		return errors.New("unknown command: " + cmd)
+	}
+	if err != nil {
+		return err
+	}
+
+	logs.Info("[migrate]", m)
+	return nil
+}
+
 func main() {
 
 	// read config
@@ -122,6 +202,16 @@ func main() {
 		return
 	}
 
+	// dean migrate [up|down|status] [version]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = migrate(&conf, os.Args[2:])
+		if err != nil {
+			logs.Error("[main] migrate failed", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// config log
 	logs.SetLogger(logs.AdapterFile, `{"filename":"./log/dean.log","level":7,"maxlines":0,"maxsize":0,"daily":true,"maxdays":10}`)
 
@@ -144,7 +234,37 @@ func main() {
 
 	models.Ac.SetStore(db)
 
+	err = models.Pm.Init(beego.AppConfig.String("permissionFile"))
+	if err != nil {
+		logs.Error("[main] load permission failed", err)
+		return
+	}
+	err = models.SSM.SetCurrent(beego.AppConfig.DefaultInt("scoreTerm", 0), beego.AppConfig.DefaultInt("scoreExam", 0))
+	if err != nil {
+		logs.Error("[main] invalid current exam", err)
+		return
+	}
+	err = models.SSM.SetThreshold(beego.AppConfig.DefaultInt("passPercent", 0),
+		beego.AppConfig.DefaultInt("excellentPercent", 0),
+		beego.AppConfig.DefaultInt("scoreBucket", 0))
+	if err != nil {
+		logs.Error("[main] invalid score threshold", err)
+		return
+	}
+	err = models.SSM.SetFullScore(beego.AppConfig.Strings("fullScore"))
+	if err != nil {
+		logs.Error("[main] invalid full score", err)
+		return
+	}
+	models.Ac.SetAdmins(strings.Split(beego.AppConfig.String("adminAccounts"), ","))
+	models.Ac.SetLockout(beego.AppConfig.DefaultInt("loginMaxFailures", 10),
+		beego.AppConfig.DefaultInt("ipMaxFailures", 50),
+		time.Duration(beego.AppConfig.DefaultInt("lockMinutes", 30))*time.Minute)
+	controllers.SetTrustedProxies(beego.AppConfig.DefaultInt("trustedProxies", 0))
+	models.Ac.SetLifetime(time.Duration(beego.AppConfig.DefaultInt("tokenIdleMinutes", 120))*time.Minute,
+		time.Duration(beego.AppConfig.DefaultInt("tokenMaxMinutes", 1440))*time.Minute)
 	models.Ac.LoadToken()
+	go models.Ac.RunSweeper()
 
 	logs.Info("server start...")
 	if beego.BConfig.RunMode == "dev" {
@@ -161,6 +281,7 @@ func main() {
 	}))
 
 	beego.InsertFilter("/*", beego.BeforeRouter, filterUser)
+	beego.InsertFilter("/*", beego.BeforeExec, filterPermission)
 
 	// 开启平滑升级
 	beego.BConfig.Listen.Graceful = true
@@ -1,11 +1,14 @@
 package models
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/juju/ratelimit"
-
 	"github.com/arong/dean/base"
 
 	"github.com/astaxie/beego/logs"
@@ -16,6 +19,16 @@ import (
 
 var Ac accessControl
 
+//go:generate mockgen -destination=./mock_password.go -source=accessControl.go PasswordStore
+type PasswordStore interface {
+	InsertPassword(*LoginInfo) error
+	UpdatePassword(id int64, userType int, password string, mustChange bool) error
+	UpdateRole(id int64, userType int, role int) error
+	UpdateAccountStatus(id int64, userType int, status int) error
+	UpdateLoginName(id int64, userType int, loginName string) error
+	ResetAllPassword(string) error
+}
+
 var (
 	//ErrTooShort      = errors.New("password too short")
 	ErrTooLong = errors.New("password too long")
@@ -32,6 +45,7 @@ type LoginKey struct {
 type LoginRequest struct {
 	LoginKey
 	Password string `json:"password"`
+	IP       string `json:"-"` // client address, for throttling
 }
 
 func (l LoginRequest) Check() error {
@@ -43,13 +57,25 @@ func (l LoginRequest) Check() error {
 	return nil
 }
 
+const (
+	defaultIdleTimeout = 2 * time.Hour
+	defaultMaxLifetime = 24 * time.Hour
+	sweepInterval      = time.Minute
+)
+
 type accessControl struct {
 	loginMap             map[LoginKey]*LoginInfo
+	loginMutex           sync.RWMutex // guard loginMap and account of LoginInfo, taken before tokenMutex
 	tokenMap             map[string]*LoginInfo
+	tokenMutex           sync.Mutex    // guard tokenMap and token of LoginInfo, reading the whole LoginInfo takes both
+	idleTimeout          time.Duration // token expires without activity
+	maxLifetime          time.Duration // token expires anyway after login
 	store                *badger.DB
 	allowDefaultPassword bool
-	defaultPassword      string // default password for student
-	blackList            map[string]bool
+	defaultPassword      string          // hash of default password for student
+	lock                 lockout         // failed login of accounts and ip
+	admins               map[string]bool // teacher granted admin by config
+	db                   PasswordStore   // persist password
 }
 
 type ResetPassReq struct {
@@ -58,16 +84,137 @@ type ResetPassReq struct {
 
 func init() {
 	Ac.tokenMap = make(map[string]*LoginInfo)
-	Ac.blackList = make(map[string]bool)
+	Ac.idleTimeout = defaultIdleTimeout
+	Ac.maxLifetime = defaultMaxLifetime
+}
+
+// SetLifetime set idle timeout and maximum lifetime of token
+func (ac *accessControl) SetLifetime(idle, max time.Duration) {
+	if idle > 0 {
+		ac.idleTimeout = idle
+	}
+	if max > 0 {
+		ac.maxLifetime = max
+	}
 }
 
 // SetStore init handler
 func (ac *accessControl) SetStore(db *badger.DB) {
 	ac.store = db
+	ac.lock.store = db
+}
+
+// SetLockout set failed login allowed within an hour for an account and an ip,
+// and how long they are locked after that
+func (ac *accessControl) SetLockout(accountLimit, ipLimit int, duration time.Duration) {
+	ac.lock.setLimit(accountLimit, ipLimit, duration)
+}
+
+// Lockouts accounts and ip locked now, with their failure history;
+// all includes the ones not locked but failed recently
+func (ac *accessControl) Lockouts(all bool) LockRecordList {
+	return ac.lock.list(time.Now(), all)
+}
+
+// Unlock clear failures of an account or an ip
+func (ac *accessControl) Unlock(req *UnlockRequest) error {
+	k, err := req.key()
+	if err != nil {
+		return err
+	}
+
+	err = ac.lock.unlock(k)
+	if err != nil {
+		return err
+	}
+	logs.Info("[accessControl::Unlock] unlocked", "key", k)
+	return nil
+}
+
+// SetAdmins grant admin to teachers by login name
+func (ac *accessControl) SetAdmins(names []string) {
+	ac.admins = make(map[string]bool)
+	for _, v := range names {
+		if v = strings.TrimSpace(v); v != "" {
+			ac.admins[v] = true
+		}
+	}
+}
+
+// Roles roles held by the account, head teacher is decided by class
+func (ac *accessControl) Roles(l LoginInfo) int {
+	if l.UserType == base.AccountTypeStudent {
+		return base.RoleStudent
+	}
+
+	role := 0
+	ac.loginMutex.RLock()
+	if v, ok := ac.loginMap[l.LoginKey()]; ok {
+		role = v.Role
+	}
+	ac.loginMutex.RUnlock()
+	if role == 0 {
+		role = base.RoleSubjectTeacher
+	}
+	if ac.admins[l.LoginName] {
+		role |= base.RoleAdmin
+	}
+	if Cm.IsMaster(l.ID) {
+		role |= base.RoleHeadTeacher
+	}
+	return role
+}
+
+// Allowed check permission of the account to run the action
+func (ac *accessControl) Allowed(l LoginInfo, method, pattern string) bool {
+	return Pm.Allowed(ac.Roles(l), method, pattern)
+}
+
+type RoleRequest struct {
+	LoginKey
+	Roles []string `json:"roles"`
+}
+
+// SetRole grant roles to a teacher, head teacher is decided by class
+func (ac *accessControl) SetRole(req *RoleRequest) error {
+	if req.UserType != base.AccountTypeTeacher {
+		return errInvalidParam
+	}
+
+	role, err := ParseRoles(req.Roles)
+	if err != nil || role&(base.RoleHeadTeacher|base.RoleStudent) != 0 {
+		return errInvalidParam
+	}
+
+	ac.loginMutex.Lock()
+	defer ac.loginMutex.Unlock()
+
+	l, ok := ac.loginMap[req.LoginKey]
+	if !ok {
+		return errNotExist
+	}
+
+	err = ac.db.UpdateRole(l.ID, l.UserType, role)
+	if err != nil {
+		logs.Warn("[accessControl::SetRole] UpdateRole failed", "err", err)
+		return err
+	}
+	l.Role = role
+
+	logs.Info("[accessControl::SetRole] role updated", "loginName", l.LoginName, "role", role)
+	return nil
+}
+
+// SetPasswordStore set storage of password
+func (ac *accessControl) SetPasswordStore(s PasswordStore) {
+	ac.db = s
 }
 
 // LoadToken load all authorised user info
 func (ac *accessControl) LoadToken() {
+	legacy := []*LoginInfo{}
+	expired := []string{}
+	now := time.Now()
 	err := ac.store.View(func(txn *badger.Txn) error {
 		opts := badger.DefaultIteratorOptions
 
@@ -77,16 +224,32 @@ func (ac *accessControl) LoadToken() {
 		for it.Rewind(); it.Valid(); it.Next() {
 			item := it.Item()
 			k := item.Key()
+			if strings.HasPrefix(string(k), lockoutPrefix) {
+				continue
+			}
 			loginInfo := LoginInfo{}
+			withPassword := false
 			err := item.Value(func(v []byte) error {
-				err := json.Unmarshal(v, &loginInfo)
-				return err
+				raw := make(map[string]json.RawMessage)
+				err := json.Unmarshal(v, &raw)
+				if err != nil {
+					return err
+				}
+				_, withPassword = raw["Password"]
+				return json.Unmarshal(v, &loginInfo)
 			})
 			if err != nil {
 				logs.Warn("[accessControl::LoadToken] invalid login info", "key", string(k))
 				continue
 			}
+			if !loginInfo.ExpireTime.After(now) {
+				expired = append(expired, string(k))
+				continue
+			}
 			ac.tokenMap[string(k)] = &loginInfo
+			if withPassword {
+				legacy = append(legacy, &loginInfo)
+			}
 		}
 		return nil
 	})
@@ -95,6 +258,19 @@ func (ac *accessControl) LoadToken() {
 		logs.Error("[accessControl::LoadToken] load token error", "err", err)
 	}
 
+	// tokens saved by older version carry the password, save them again without it
+	for _, v := range legacy {
+		ac.storeToken(v)
+	}
+
+	// tokens saved by older version never expire, they are dropped here
+	for _, v := range expired {
+		ac.removeToken(v)
+	}
+	logs.Info("[accessControl::LoadToken] token loaded", "valid", len(ac.tokenMap), "expired", len(expired))
+
+	ac.lock.load(now)
+
 	_ = ac.store.View(func(txn *badger.Txn) error {
 		item, err := txn.Get([]byte("AllowDefaultPassword"))
 		if err != nil {
@@ -124,131 +300,317 @@ func (ac *accessControl) LoadToken() {
 		ac.defaultPassword = string(valCopy)
 		return nil
 	})
+
+	// default password saved by older version is plaintext
+	if ac.defaultPassword != "" && !isHashed(ac.defaultPassword) {
+		hash, err := hashPassword(ac.defaultPassword)
+		if err != nil {
+			logs.Error("[accessControl::LoadToken] hash default password failed", "err", err)
+			return
+		}
+		ac.defaultPassword = hash
+		ac.saveDefaultPassword()
+	}
 }
 
 type LoginInfo struct {
 	UserType     int
 	ID           int64
 	LoginName    string
-	Password     string
+	Password     string `json:"-"` // bcrypt hash, plaintext for rows not upgraded yet
+	Role         int    `json:"-"` // role granted, see base.RoleAdmin
+	Status       int    `json:"-"` // base.AccountStatusActive or base.AccountStatusDisabled
+	MustChange   bool   // password must be changed before anything else
 	CurrentToken string
-	ExpireTime   time.Time         // expire time of the token
-	Bucket       *ratelimit.Bucket // maximum try time
+	Secret       string    // key to sign request, issued with the token
+	IssueTime    time.Time // login time, token lives no longer than maxLifetime
+	ExpireTime   time.Time // expire time of the token
 }
 
-// Login: authorise user and issue token
-func (ac *accessControl) Login(req *LoginRequest) (string, error) {
-	token := ""
+// LoginKey key of the account in loginMap
+func (l LoginInfo) LoginKey() LoginKey {
+	return LoginKey{UserType: l.UserType, LoginName: l.LoginName}
+}
 
-	// check black list
-	{
-		_, ok := ac.blackList[req.LoginName]
-		if ok {
-			logs.Warn("[accessControl::Login] maybe attack", "login name", req.LoginName)
-			return "", errPermission
-		}
+// String keep password out of logs
+func (l LoginInfo) String() string {
+	return fmt.Sprintf("{UserType:%d ID:%d LoginName:%s}", l.UserType, l.ID, l.LoginName)
+}
+
+// LoginResult token and the secret to sign the following requests
+type LoginResult struct {
+	Token      string `json:"token"`
+	Secret     string `json:"secret"`
+	MustChange bool   `json:"must_change"` // only /auth/update is allowed until password changed
+}
+
+// Login: authorise user and issue token with the secret to sign request
+func (ac *accessControl) Login(req *LoginRequest) (*LoginResult, error) {
+	now := time.Now()
+
+	err := ac.lock.check(req.LoginKey, req.IP, now)
+	if err != nil {
+		return nil, err
 	}
 
+	// password is verified out of the lock, it takes a while
+	ac.loginMutex.RLock()
 	l, ok := ac.loginMap[req.LoginKey]
+	hash := ""
+	if ok {
+		hash = l.Password
+	}
+	ac.loginMutex.RUnlock()
+
 	if !ok {
 		if req.UserType != base.AccountTypeStudent {
 			logs.Debug("[accessControl::Login] User not found", req.LoginName)
-			return token, errNotExist
+			ac.lock.fail(req.LoginKey, req.IP, now)
+			return nil, errNotExist
 		}
 		student, err := Um.GetStudentByRegisterNumber(req.LoginName)
 		if err != nil {
 			logs.Debug("[accessControl::Login] student not found", req.LoginName)
-			return "", err
+			verifyPassword("", req.Password)
+			ac.lock.fail(req.LoginKey, req.IP, now)
+			return nil, err
+		}
+		// student without password logs in with the default one
+		if !verifyPassword(ac.defaultPassword, req.Password) {
+			logs.Info("[accessControl::Login] default password not match")
+			ac.lock.fail(req.LoginKey, req.IP, now)
+			return nil, errPermission
+		}
+		if !ac.allowDefaultPassword {
+			logs.Info("[accessControl::Login] default password not allowed")
+			return nil, ErrDefaultPassword
 		}
 		l = &LoginInfo{
-			UserType:  base.AccountTypeStudent,
-			ID:        student.StudentID,
-			LoginName: student.RegisterID,
-			Password:  ac.defaultPassword,
+			UserType:   base.AccountTypeStudent,
+			ID:         student.StudentID,
+			LoginName:  student.RegisterID,
+			Password:   ac.defaultPassword,
+			Status:     base.AccountStatusActive,
+			MustChange: true,
 		}
-		err = Ma.InsertPassword(l)
+		err = ac.db.InsertPassword(l)
 		if err != nil {
-			logs.Debug("[accessControl::Login] InsertPassword failed")
-			return "", nil
+			logs.Debug("[accessControl::Login] InsertPassword failed", "err", err)
+			return nil, err
 		}
+		ac.loginMutex.Lock()
 		ac.loginMap[req.LoginKey] = l
-	} else if req.Password != l.Password {
+		ac.loginMutex.Unlock()
+	} else if !verifyPassword(hash, req.Password) {
 		logs.Info("[accessControl::Login] password not match")
-		if l.Bucket == nil {
-			l.Bucket = ratelimit.NewBucket(time.Hour, 10)
-		}
-		if l.Bucket.TakeAvailable(1) <= 0 {
-			ac.blackList[l.LoginName] = true
+		ac.lock.fail(req.LoginKey, req.IP, now)
+		return nil, errPermission
+	}
+
+	ac.loginMutex.Lock()
+	if l.Status == base.AccountStatusDisabled {
+		ac.loginMutex.Unlock()
+		logs.Info("[accessControl::Login] account disabled", "loginName", l.LoginName)
+		return nil, ErrDisabled
+	}
+
+	// still on the default password, reset assigns the same hash
+	if l.UserType == base.AccountTypeStudent && ac.defaultPassword != "" && l.Password == ac.defaultPassword {
+		if !ac.allowDefaultPassword {
+			ac.loginMutex.Unlock()
+			logs.Info("[accessControl::Login] default password not allowed", "loginName", l.LoginName)
+			return nil, ErrDefaultPassword
 		}
-		return token, errPermission
+		l.MustChange = true
 	}
+	mustChange := l.MustChange
+	hashed := isHashed(l.Password)
+	ac.loginMutex.Unlock()
 
-	// remove bucket after success login
-	l.Bucket = nil
+	// failures of the account are cleared after success login, the ip's are kept
+	ac.lock.succeed(req.LoginKey)
 
-	if l.CurrentToken != "" {
-		logs.Debug("[accessControl::Login] remove token", l.CurrentToken)
-		delete(ac.tokenMap, l.CurrentToken)
-		ac.removeToken(l.CurrentToken)
+	// upgrade plaintext password
+	if !hashed {
+		ac.upgradePassword(l, req.Password)
 	}
 
-	token = uuid.New().String()
+	secret, err := newSecret()
+	if err != nil {
+		logs.Error("[accessControl::Login] generate secret failed", "err", err)
+		return nil, err
+	}
+
+	ac.loginMutex.RLock()
+	defer ac.loginMutex.RUnlock()
+	ac.tokenMutex.Lock()
+	defer ac.tokenMutex.Unlock()
+
+	// sign out previous sessions, tokens loaded from store are not the ones
+	// in loginMap, so they are matched by login key
+	list := []string{}
+	for k, v := range ac.tokenMap {
+		if v.UserType == l.UserType && v.LoginName == l.LoginName {
+			list = append(list, k)
+		}
+	}
+	for _, v := range list {
+		logs.Debug("[accessControl::Login] remove token", v)
+		ac.dropToken(v)
+	}
+
+	token := uuid.New().String()
 	l.CurrentToken = token
+	l.Secret = secret
+	l.IssueTime = now
+	l.ExpireTime = ac.expireTime(l, now)
 	ac.tokenMap[token] = l
 
 	// 保存token
 	ac.storeToken(l)
 
-	return token, nil
+	return &LoginResult{Token: token, Secret: secret, MustChange: mustChange}, nil
+}
+
+// newSecret random key of a session
+func newSecret() (string, error) {
+	buff := make([]byte, 32)
+	_, err := rand.Read(buff)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buff), nil
 }
 
 type UpdateRequest struct {
 	LoginKey
-	Password string
+	Password     string
+	CurrentToken string `json:"-"` // session making the change, kept alive
 }
 
 func (ac *accessControl) Update(req *UpdateRequest) error {
+	ac.loginMutex.RLock()
 	l, ok := ac.loginMap[req.LoginKey]
+	hash, mustChange := "", false
+	if ok {
+		hash, mustChange = l.Password, l.MustChange
+	}
+	ac.loginMutex.RUnlock()
+
 	if !ok {
 		logs.Debug("[accessControl::Update] user not exist")
 		return errNotExist
 	}
 
-	if l.Password == req.Password {
+	if verifyPassword(hash, req.Password) {
+		// the initial password must be replaced
+		if mustChange {
+			return ErrSamePassword
+		}
 		logs.Info("[accessControl::Update] nothing to do")
 		return nil
 	}
 
-	err := Ma.UpdatePassword(l.ID, req.Password)
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		logs.Warn("[accessControl::Update] hash password failed", "err", err)
+		return err
+	}
+
+	err = ac.db.UpdatePassword(l.ID, l.UserType, hash, false)
 	if err != nil {
 		logs.Warn("[accessControl::Update] UpdatePassword failed", "err", err)
 		return err
 	}
 
-	l.Password = req.Password
+	ac.loginMutex.Lock()
+	l.Password = hash
+	l.MustChange = false
+	ac.loginMutex.Unlock()
+	if mustChange {
+		ac.changed(req.CurrentToken)
+	}
 
-	logs.Info("[accessControl::Update] update password success", "loginName", req.LoginName, "password", req.Password)
+	// sign out other sessions of the account
+	count := ac.revoke(func(v *LoginInfo) bool {
+		return v.UserType == req.UserType && v.LoginName == req.LoginName && v.CurrentToken != req.CurrentToken
+	})
+
+	logs.Info("[accessControl::Update] update password success", "loginName", req.LoginName, "revoked", count)
 	return nil
 }
 
+// changed lift the restriction of the session after password changed
+func (ac *accessControl) changed(token string) {
+	ac.loginMutex.Lock()
+	defer ac.loginMutex.Unlock()
+	ac.tokenMutex.Lock()
+	defer ac.tokenMutex.Unlock()
+
+	// tokens loaded from store are not the ones in loginMap
+	if v, ok := ac.tokenMap[token]; ok {
+		v.MustChange = false
+		ac.storeToken(v)
+	}
+}
+
+// upgradePassword replace plaintext password with its hash, login goes on if it fails
+func (ac *accessControl) upgradePassword(l *LoginInfo, password string) {
+	hash, err := hashPassword(password)
+	if err != nil {
+		logs.Warn("[accessControl::upgradePassword] hash password failed", "err", err)
+		return
+	}
+
+	ac.loginMutex.RLock()
+	mustChange := l.MustChange
+	ac.loginMutex.RUnlock()
+
+	err = ac.db.UpdatePassword(l.ID, l.UserType, hash, mustChange)
+	if err != nil {
+		logs.Warn("[accessControl::upgradePassword] UpdatePassword failed", "loginName", l.LoginName, "err", err)
+		return
+	}
+	ac.loginMutex.Lock()
+	l.Password = hash
+	ac.loginMutex.Unlock()
+	logs.Info("[accessControl::upgradePassword] password upgraded", "loginName", l.LoginName)
+}
+
 // ResetAllStudentPassword reset all students' password to default value
 func (ac *accessControl) ResetAllStudentPassword(req *ResetPassReq) error {
-	ac.defaultPassword = req.Password
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		logs.Warn("[ResetAllStudentPassword] hash password failed", "err", err)
+		return err
+	}
+
 	// drop all students's password in db
-	err := Ma.ResetAllPassword(ac.defaultPassword)
+	err = ac.db.ResetAllPassword(hash)
 	if err != nil {
 		logs.Warn("[ResetAllStudentPassword] DropAllPassword failed", "err", err)
 		return err
 	}
+	ac.defaultPassword = hash
 
 	// flush password
+	ac.loginMutex.Lock()
 	for _, v := range ac.loginMap {
 		if v.UserType == base.AccountTypeStudent {
-			v.Password = req.Password
+			v.Password = hash
+			v.MustChange = true
 		}
 	}
+	ac.loginMutex.Unlock()
+
+	// sign out all students
+	count := ac.revoke(func(v *LoginInfo) bool {
+		return v.UserType == base.AccountTypeStudent
+	})
+	logs.Info("[ResetAllStudentPassword] student sessions revoked", "count", count)
 
 	// set flag
+	ac.allowDefaultPassword = true
 	err = ac.store.Update(func(txn *badger.Txn) error {
 		return txn.Set([]byte("AllowDefaultPassword"), []byte("true"))
 	})
@@ -258,17 +620,58 @@ func (ac *accessControl) ResetAllStudentPassword(req *ResetPassReq) error {
 	}
 
 	// set password
-	err = ac.store.Update(func(txn *badger.Txn) error {
-		return txn.Set([]byte("DefaultPassword"), []byte(ac.defaultPassword))
-	})
+	ac.saveDefaultPassword()
+	return nil
+}
+
+// ResetStudentPassword reset password of one student to the default value,
+// it must be changed on next login
+func (ac *accessControl) ResetStudentPassword(studentID int64) error {
+	if !Um.IsExist(studentID) {
+		return errNotExist
+	}
+
+	if ac.defaultPassword == "" || !ac.allowDefaultPassword {
+		logs.Info("[accessControl::ResetStudentPassword] default password not set")
+		return ErrDefaultPassword
+	}
 
+	ac.loginMutex.Lock()
+	defer ac.loginMutex.Unlock()
+
+	l := ac.findAccount(base.AccountTypeStudent, studentID)
+	if l == nil {
+		// never logged in, the default password works already
+		logs.Debug("[accessControl::ResetStudentPassword] account not created yet", "studentID", studentID)
+		return nil
+	}
+
+	err := ac.db.UpdatePassword(l.ID, l.UserType, ac.defaultPassword, true)
 	if err != nil {
-		logs.Warn("[ResetAllStudentPassword] set cache failed", "err", err)
+		logs.Warn("[accessControl::ResetStudentPassword] UpdatePassword failed", "err", err)
+		return err
 	}
+	l.Password = ac.defaultPassword
+	l.MustChange = true
 
+	count := ac.revoke(func(v *LoginInfo) bool {
+		return v.UserType == l.UserType && v.LoginName == l.LoginName
+	})
+	logs.Info("[accessControl::ResetStudentPassword] password reset", "studentID", studentID, "revoked", count)
 	return nil
 }
 
+func (ac *accessControl) saveDefaultPassword() {
+	err := ac.store.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("DefaultPassword"), []byte(ac.defaultPassword))
+	})
+
+	if err != nil {
+		logs.Warn("[accessControl::saveDefaultPassword] set cache failed", "err", err)
+	}
+}
+
+// storeToken persist the token, caller holds loginMutex and tokenMutex
 func (ac *accessControl) storeToken(l *LoginInfo) {
 	buffer, err := json.Marshal(l)
 	if err != nil {
@@ -299,17 +702,155 @@ func (ac *accessControl) removeToken(token string) {
 	}
 }
 
-// VerifyToken check to see if the token is valid
+// expireTime idle timeout from now, but no later than the maximum lifetime
+func (ac *accessControl) expireTime(l *LoginInfo, now time.Time) time.Time {
+	expire := now.Add(ac.idleTimeout)
+	if limit := l.IssueTime.Add(ac.maxLifetime); expire.After(limit) {
+		expire = limit
+	}
+	return expire
+}
+
+// VerifyToken check to see if the token is valid, and renew it on success
 func (ac *accessControl) VerifyToken(token string) (LoginInfo, bool) {
+	ac.loginMutex.RLock()
+	defer ac.loginMutex.RUnlock()
+	ac.tokenMutex.Lock()
+	defer ac.tokenMutex.Unlock()
+
 	l, ok := ac.tokenMap[token]
 	if !ok {
 		return LoginInfo{}, false
 	}
+
+	now := time.Now()
+	if !l.ExpireTime.After(now) {
+		logs.Info("[accessControl::VerifyToken] token expired", "loginName", l.LoginName)
+		ac.dropToken(token)
+		return LoginInfo{}, false
+	}
+
+	// sliding renewal, persist at most once a minute
+	expire := ac.expireTime(l, now)
+	if expire.After(l.ExpireTime) {
+		persist := expire.Sub(l.ExpireTime) >= time.Minute
+		l.ExpireTime = expire
+		if persist {
+			ac.storeToken(l)
+		}
+	}
 	return *l, ok
 }
 
+// dropToken remove token from memory and store, caller holds tokenMutex
+func (ac *accessControl) dropToken(token string) {
+	if l, ok := ac.tokenMap[token]; ok && l.CurrentToken == token {
+		l.CurrentToken = ""
+	}
+	delete(ac.tokenMap, token)
+	ac.removeToken(token)
+}
+
+// RunSweeper delete expired tokens periodically
+func (ac *accessControl) RunSweeper() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		ac.sweep(now)
+	}
+}
+
+// sweep delete expired tokens in store, including the ones not in memory
+func (ac *accessControl) sweep(now time.Time) {
+	expired := []string{}
+	err := ac.store.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			loginInfo := LoginInfo{}
+			err := item.Value(func(v []byte) error {
+				return json.Unmarshal(v, &loginInfo)
+			})
+			// not a token
+			if err != nil || loginInfo.CurrentToken == "" {
+				continue
+			}
+			if !loginInfo.ExpireTime.After(now) {
+				expired = append(expired, string(item.KeyCopy(nil)))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Warn("[accessControl::sweep] iterate store failed", "err", err)
+		return
+	}
+
+	ac.loginMutex.RLock()
+	defer ac.loginMutex.RUnlock()
+	ac.tokenMutex.Lock()
+	defer ac.tokenMutex.Unlock()
+
+	for _, token := range expired {
+		// renewed in memory but not persisted yet
+		if l, ok := ac.tokenMap[token]; ok && l.ExpireTime.After(now) {
+			ac.storeToken(l)
+			continue
+		}
+		ac.dropToken(token)
+	}
+
+	if len(expired) > 0 {
+		logs.Info("[accessControl::sweep] expired token removed", "count", len(expired))
+	}
+
+	ac.lock.sweep(now)
+}
+
 // Logout: logout current user from system
 func (ac *accessControl) Logout(token string) error {
-	delete(ac.tokenMap, token)
+	ac.tokenMutex.Lock()
+	defer ac.tokenMutex.Unlock()
+
+	if _, ok := ac.tokenMap[token]; !ok {
+		return errNotExist
+	}
+	ac.dropToken(token)
 	return nil
 }
+
+// ForceLogout sign out every session of the account, return the count revoked
+func (ac *accessControl) ForceLogout(key LoginKey) (int, error) {
+	if key.LoginName == "" {
+		return 0, errInvalidParam
+	}
+
+	ac.loginMutex.RLock()
+	count := ac.revoke(func(v *LoginInfo) bool {
+		return v.UserType == key.UserType && v.LoginName == key.LoginName
+	})
+	ac.loginMutex.RUnlock()
+	logs.Info("[accessControl::ForceLogout] sessions revoked", "loginName", key.LoginName, "count", count)
+	return count, nil
+}
+
+// revoke drop tokens matched
+func (ac *accessControl) revoke(match func(*LoginInfo) bool) int {
+	ac.tokenMutex.Lock()
+	defer ac.tokenMutex.Unlock()
+
+	list := []string{}
+	for k, v := range ac.tokenMap {
+		if match(v) {
+			list = append(list, k)
+		}
+	}
+
+	for _, v := range list {
+		ac.dropToken(v)
+	}
+	return len(list)
+}
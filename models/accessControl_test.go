@@ -0,0 +1,375 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+
+	"github.com/arong/dean/base"
+)
+
+func TestVerifyPassword(t *testing.T) {
+	hash, err := hashPassword("secret")
+	if err != nil || !isHashed(hash) || hash == "secret" {
+		t.Fatal("hash password failed", err)
+	}
+
+	if !verifyPassword(hash, "secret") || verifyPassword(hash, "Secret") {
+		t.Fatal("verify hashed password failed")
+	}
+
+	// rows not upgraded yet
+	if isHashed("secret") || !verifyPassword("secret", "secret") || verifyPassword("secret", "secret1") {
+		t.Fatal("verify plaintext password failed")
+	}
+
+	if verifyPassword("", "") {
+		t.Fatal("empty password passed")
+	}
+}
+
+func TestAccessControl_Update(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	key := LoginKey{UserType: base.AccountTypeTeacher, LoginName: "zhao"}
+	mockStore := NewMockPasswordStore(mockCtrl)
+	ac := accessControl{db: mockStore, loginMap: map[LoginKey]*LoginInfo{key: {ID: 1, UserType: base.AccountTypeTeacher, LoginName: "zhao", Password: "old"}}}
+
+	// same password
+	err := ac.Update(&UpdateRequest{LoginKey: key, Password: "old"})
+	if err != nil {
+		t.Fatal("update failed", err)
+	}
+
+	mockStore.EXPECT().UpdatePassword(int64(1), base.AccountTypeTeacher, gomock.Any(), false).Return(errors.New("sank your ship"))
+	err = ac.Update(&UpdateRequest{LoginKey: key, Password: "new"})
+	if err == nil || ac.loginMap[key].Password != "old" {
+		t.Fatal("logic error")
+	}
+
+	stored := ""
+	mockStore.EXPECT().UpdatePassword(int64(1), base.AccountTypeTeacher, gomock.Any(), false).DoAndReturn(func(id int64, userType int, password string, mustChange bool) error {
+		stored = password
+		return nil
+	})
+	err = ac.Update(&UpdateRequest{LoginKey: key, Password: "new"})
+	if err != nil || stored == "new" || !verifyPassword(stored, "new") || ac.loginMap[key].Password != stored {
+		t.Fatal("password not hashed", err)
+	}
+}
+
+func newTokenStore(t *testing.T) (*badger.DB, func()) {
+	dir, err := ioutil.TempDir("", "badger")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := badger.Open(badger.DefaultOptions(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func tokenExist(db *badger.DB, token string) bool {
+	err := db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte(token))
+		return err
+	})
+	return err == nil
+}
+
+func TestAccessControl_VerifyToken(t *testing.T) {
+	db, closer := newTokenStore(t)
+	defer closer()
+
+	now := time.Now()
+	ac := accessControl{store: db, tokenMap: make(map[string]*LoginInfo), idleTimeout: time.Hour, maxLifetime: 3 * time.Hour}
+	ac.tokenMap["a"] = &LoginInfo{CurrentToken: "a", IssueTime: now.Add(-time.Hour), ExpireTime: now.Add(time.Minute)}
+	ac.tokenMap["b"] = &LoginInfo{CurrentToken: "b", IssueTime: now.Add(-time.Hour), ExpireTime: now.Add(-time.Second)}
+	ac.tokenMap["c"] = &LoginInfo{CurrentToken: "c", IssueTime: now.Add(-170 * time.Minute), ExpireTime: now.Add(time.Minute)}
+	for _, v := range ac.tokenMap {
+		ac.storeToken(v)
+	}
+
+	// renewed by idle timeout
+	l, ok := ac.VerifyToken("a")
+	if !ok || l.ExpireTime.Before(now.Add(59*time.Minute)) {
+		t.Fatal("token not renewed", l.ExpireTime)
+	}
+
+	// expired
+	_, ok = ac.VerifyToken("b")
+	if ok || tokenExist(db, "b") {
+		t.Fatal("expired token accepted")
+	}
+
+	// renewed up to the maximum lifetime
+	l, ok = ac.VerifyToken("c")
+	if !ok || l.ExpireTime.After(now.Add(11*time.Minute)) {
+		t.Fatal("token renewed beyond maximum lifetime", l.ExpireTime)
+	}
+}
+
+func TestAccessControl_sweep(t *testing.T) {
+	db, closer := newTokenStore(t)
+	defer closer()
+
+	now := time.Now()
+	ac := accessControl{store: db, tokenMap: make(map[string]*LoginInfo), idleTimeout: time.Hour, maxLifetime: 3 * time.Hour}
+	ac.defaultPassword = "hash"
+	ac.saveDefaultPassword()
+
+	ac.storeToken(&LoginInfo{CurrentToken: "a", ExpireTime: now.Add(time.Minute)})
+	ac.storeToken(&LoginInfo{CurrentToken: "b", ExpireTime: now.Add(-time.Minute)})
+	ac.tokenMap["b"] = &LoginInfo{CurrentToken: "b", ExpireTime: now.Add(-time.Minute)}
+
+	ac.sweep(now)
+	if !tokenExist(db, "a") || tokenExist(db, "b") || !tokenExist(db, "DefaultPassword") {
+		t.Fatal("sweep failed")
+	}
+
+	if _, ok := ac.tokenMap["b"]; ok {
+		t.Fatal("expired token kept in memory")
+	}
+}
+
+func TestAccessControl_revoke(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db, closer := newTokenStore(t)
+	defer closer()
+
+	mockStore := NewMockPasswordStore(mockCtrl)
+	now := time.Now()
+	ac := accessControl{db: mockStore, store: db, tokenMap: make(map[string]*LoginInfo), loginMap: make(map[LoginKey]*LoginInfo)}
+	teacher := LoginKey{UserType: base.AccountTypeTeacher, LoginName: "zhao"}
+	ac.loginMap[teacher] = &LoginInfo{UserType: base.AccountTypeTeacher, ID: 1, LoginName: "zhao", Password: "old"}
+
+	for _, v := range []*LoginInfo{
+		{UserType: base.AccountTypeTeacher, LoginName: "zhao", CurrentToken: "t1"},
+		{UserType: base.AccountTypeTeacher, LoginName: "zhao", CurrentToken: "t2"},
+		{UserType: base.AccountTypeTeacher, LoginName: "qian", CurrentToken: "t3"},
+		{UserType: base.AccountTypeStudent, LoginName: "2019001", CurrentToken: "s1"},
+		{UserType: base.AccountTypeStudent, LoginName: "2019002", CurrentToken: "s2"},
+	} {
+		v.ExpireTime = now.Add(time.Hour)
+		ac.tokenMap[v.CurrentToken] = v
+		ac.storeToken(v)
+	}
+
+	// logout removes the persisted token
+	err := ac.Logout("s2")
+	if err != nil || tokenExist(db, "s2") {
+		t.Fatal("logout failed", err)
+	}
+
+	// password change keeps the current session only
+	mockStore.EXPECT().UpdatePassword(int64(1), gomock.Any(), gomock.Any(), false).Return(nil)
+	err = ac.Update(&UpdateRequest{LoginKey: teacher, Password: "new", CurrentToken: "t1"})
+	if err != nil {
+		t.Fatal("update failed", err)
+	}
+	if _, ok := ac.VerifyToken("t1"); !ok || tokenExist(db, "t2") {
+		t.Fatal("other session not revoked")
+	}
+
+	mockStore.EXPECT().ResetAllPassword(gomock.Any()).Return(nil)
+	err = ac.ResetAllStudentPassword(&ResetPassReq{Password: "default"})
+	if err != nil || tokenExist(db, "s1") {
+		t.Fatal("student session not revoked", err)
+	}
+
+	count, err := ac.ForceLogout(LoginKey{UserType: base.AccountTypeTeacher, LoginName: "qian"})
+	if err != nil || count != 1 || tokenExist(db, "t3") || !tokenExist(db, "t1") {
+		t.Fatal("force logout failed", count, err)
+	}
+}
+
+func TestAccessControl_SetRole(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	teacher := LoginKey{UserType: base.AccountTypeTeacher, LoginName: "zhao"}
+	student := LoginKey{UserType: base.AccountTypeStudent, LoginName: "qian"}
+	mockStore := NewMockPasswordStore(mockCtrl)
+	ac := accessControl{db: mockStore, loginMap: map[LoginKey]*LoginInfo{
+		teacher: {ID: 2, UserType: base.AccountTypeTeacher, LoginName: "zhao"},
+		student: {ID: 2, UserType: base.AccountTypeStudent, LoginName: "qian"},
+	}}
+	ac.SetAdmins([]string{" admin ", ""})
+
+	Cm = classManager{idMap: map[int]*Class{1: newClass()}}
+	defer func() { Cm = classManager{} }()
+
+	zhao := *ac.loginMap[teacher]
+	if ac.Roles(zhao) != base.RoleSubjectTeacher {
+		t.Fatal("default role should be subject teacher")
+	}
+	if ac.Roles(*ac.loginMap[student]) != base.RoleStudent {
+		t.Fatal("student role mismatch")
+	}
+	if ac.Roles(LoginInfo{ID: 1, UserType: base.AccountTypeTeacher, LoginName: "admin"}) !=
+		base.RoleAdmin|base.RoleSubjectTeacher|base.RoleHeadTeacher {
+		t.Fatal("admin or head teacher not detected")
+	}
+
+	for _, v := range []*RoleRequest{
+		{LoginKey: student, Roles: []string{"dean"}},
+		{LoginKey: teacher, Roles: []string{"head_teacher"}},
+		{LoginKey: teacher, Roles: []string{"janitor"}},
+	} {
+		if ac.SetRole(v) == nil {
+			t.Fatal("invalid request accepted", v)
+		}
+	}
+
+	err := ac.SetRole(&RoleRequest{LoginKey: LoginKey{UserType: base.AccountTypeTeacher, LoginName: "sun"}, Roles: []string{"dean"}})
+	if err != errNotExist {
+		t.Fatal("unknown account accepted", err)
+	}
+
+	mockStore.EXPECT().UpdateRole(int64(2), base.AccountTypeTeacher, base.RoleDeanOffice).Return(errors.New("sank your ship"))
+	err = ac.SetRole(&RoleRequest{LoginKey: teacher, Roles: []string{"dean"}})
+	if err == nil || ac.Roles(zhao) != base.RoleSubjectTeacher {
+		t.Fatal("role changed on failure")
+	}
+
+	mockStore.EXPECT().UpdateRole(int64(2), base.AccountTypeTeacher, base.RoleDeanOffice|base.RoleSubjectTeacher).Return(nil)
+	err = ac.SetRole(&RoleRequest{LoginKey: teacher, Roles: []string{"dean", "subject_teacher"}})
+	if err != nil || ac.Roles(zhao) != base.RoleDeanOffice|base.RoleSubjectTeacher {
+		t.Fatal("set role failed", err)
+	}
+
+	// roles and tokens are checked on every request while accounts change
+	ac.tokenMap = map[string]*LoginInfo{"t": ac.loginMap[teacher]}
+	ac.loginMap[teacher].CurrentToken = "t"
+	ac.loginMap[teacher].ExpireTime = time.Now().Add(time.Hour)
+	stop := make(chan struct{})
+	wg, started := sync.WaitGroup{}, sync.WaitGroup{}
+	for _, check := range []func(){func() { ac.Roles(zhao) }, func() { ac.VerifyToken("t") }} {
+		wg.Add(1)
+		started.Add(1)
+		go func(check func()) {
+			defer wg.Done()
+			check()
+			started.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					check()
+				}
+			}
+		}(check)
+	}
+	started.Wait()
+	mockStore.EXPECT().UpdateRole(int64(2), base.AccountTypeTeacher, base.RoleSubjectTeacher).Return(nil)
+	if err = ac.SetRole(&RoleRequest{LoginKey: teacher, Roles: []string{"subject_teacher"}}); err != nil {
+		t.Fatal(err)
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestAccessControl_LoginAfterReload(t *testing.T) {
+	db, closer := newTokenStore(t)
+	defer closer()
+
+	key := LoginKey{UserType: base.AccountTypeTeacher, LoginName: "zhao"}
+	hash, _ := hashPassword("secret")
+	ac := accessControl{tokenMap: make(map[string]*LoginInfo), loginMap: map[LoginKey]*LoginInfo{
+		key: {ID: 1, UserType: base.AccountTypeTeacher, LoginName: "zhao", Password: hash, Status: base.AccountStatusActive},
+	}}
+	ac.SetStore(db)
+	ac.SetLifetime(defaultIdleTimeout, defaultMaxLifetime)
+
+	// token saved before restart is loaded apart from the account
+	now := time.Now()
+	ac.storeToken(&LoginInfo{ID: 1, UserType: base.AccountTypeTeacher, LoginName: "zhao", CurrentToken: "old", IssueTime: now, ExpireTime: now.Add(time.Hour)})
+	ac.LoadToken()
+	if _, ok := ac.tokenMap["old"]; !ok {
+		t.Fatal("token not loaded")
+	}
+
+	ret, err := ac.Login(&LoginRequest{LoginKey: key, Password: "secret"})
+	if err != nil {
+		t.Fatal("login failed", err)
+	}
+	if _, ok := ac.VerifyToken("old"); ok || tokenExist(db, "old") {
+		t.Fatal("previous session alive after login")
+	}
+	if _, ok := ac.VerifyToken(ret.Token); !ok {
+		t.Fatal("new session not found")
+	}
+}
+
+func TestAccessControl_DefaultPassword(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db, closer := newTokenStore(t)
+	defer closer()
+
+	Um.Init(map[int64]*StudentInfo{1: {StudentID: 1, RegisterID: "2019001"}})
+	defer Um.Init(nil)
+
+	hash, _ := hashPassword("default")
+	key := LoginKey{UserType: base.AccountTypeStudent, LoginName: "2019001"}
+	mockStore := NewMockPasswordStore(mockCtrl)
+	ac := accessControl{db: mockStore, store: db, defaultPassword: hash,
+		tokenMap: make(map[string]*LoginInfo), loginMap: make(map[LoginKey]*LoginInfo)}
+	ac.SetLifetime(defaultIdleTimeout, defaultMaxLifetime)
+
+	if _, err := ac.Login(&LoginRequest{LoginKey: key, Password: "default"}); err != ErrDefaultPassword {
+		t.Fatal("default password not allowed", err)
+	}
+	if err := ac.ResetStudentPassword(1); err != ErrDefaultPassword {
+		t.Fatal("reset without default password", err)
+	}
+
+	ac.allowDefaultPassword = true
+	mockStore.EXPECT().InsertPassword(gomock.Any()).DoAndReturn(func(l *LoginInfo) error {
+		if !l.MustChange {
+			t.Fatal("first login should change password")
+		}
+		return nil
+	})
+	ret, err := ac.Login(&LoginRequest{LoginKey: key, Password: "default"})
+	if err != nil || !ret.MustChange {
+		t.Fatal("login failed", ret, err)
+	}
+
+	mockStore.EXPECT().UpdatePassword(int64(1), base.AccountTypeStudent, gomock.Any(), false).Return(nil)
+	err = ac.Update(&UpdateRequest{LoginKey: key, Password: "mine", CurrentToken: ret.Token})
+	if err != nil {
+		t.Fatal("update failed", err)
+	}
+	if ret, err = ac.Login(&LoginRequest{LoginKey: key, Password: "mine"}); err != nil || ret.MustChange {
+		t.Fatal("changed password still restricted", err)
+	}
+
+	if err = ac.ResetStudentPassword(2); err != errNotExist {
+		t.Fatal("reset unknown student", err)
+	}
+	mockStore.EXPECT().UpdatePassword(int64(1), base.AccountTypeStudent, hash, true).Return(nil)
+	err = ac.ResetStudentPassword(1)
+	if _, ok := ac.VerifyToken(ret.Token); err != nil || ok {
+		t.Fatal("reset failed", err)
+	}
+	if ret, err = ac.Login(&LoginRequest{LoginKey: key, Password: "default"}); err != nil || !ret.MustChange {
+		t.Fatal("login after reset failed", err)
+	}
+}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/arong/dean/base"
+	"github.com/astaxie/beego/logs"
+)
+
+// initialPasswordLength length of the generated initial password
+const initialPasswordLength = 10
+
+// Credential login name and initial password of an account, shown only once
+type Credential struct {
+	TeacherID int64  `json:"teacher_id"`
+	LoginName string `json:"login_name"`
+	Password  string `json:"password"`
+}
+
+// findAccount account of the user, nil if not found. caller holds loginMutex
+func (ac *accessControl) findAccount(userType int, id int64) *LoginInfo {
+	for _, v := range ac.loginMap {
+		if v.UserType == userType && v.ID == id {
+			return v
+		}
+	}
+	return nil
+}
+
+// initialPassword generate a password and its hash, the hash is of what the
+// client sends, see clientDigest
+func initialPassword() (string, string, error) {
+	password, err := randomPassword(initialPasswordLength)
+	if err != nil {
+		return "", "", err
+	}
+
+	hash, err := hashPassword(clientDigest(password))
+	if err != nil {
+		return "", "", err
+	}
+	return password, hash, nil
+}
+
+// ProvisionTeacher create account of the teacher with a random initial password,
+// which must be changed on first login. loginName defaults to the mobile of the
+// teacher, or the name if there is no mobile. names of admin are rejected
+func (ac *accessControl) ProvisionTeacher(teacherID int64, loginName string) (*Credential, error) {
+	t, err := Tm.GetTeacherInfo(teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	ac.loginMutex.Lock()
+	defer ac.loginMutex.Unlock()
+
+	if ac.findAccount(base.AccountTypeTeacher, teacherID) != nil {
+		logs.Debug("[accessControl::ProvisionTeacher] account exist", "teacherID", teacherID)
+		return nil, errExist
+	}
+
+	loginName = strings.TrimSpace(loginName)
+	if loginName == "" {
+		loginName = t.Mobile
+	}
+	if loginName == "" {
+		loginName = t.Name
+	}
+
+	// admin is granted by login name, it can't be taken by provisioning
+	if ac.Reserved(loginName) {
+		logs.Warn("[accessControl::ProvisionTeacher] login name reserved", "teacherID", teacherID, "loginName", loginName)
+		return nil, ErrLoginNameReserved
+	}
+
+	key := LoginKey{UserType: base.AccountTypeTeacher, LoginName: loginName}
+	if _, ok := ac.loginMap[key]; ok {
+		logs.Debug("[accessControl::ProvisionTeacher] login name exist", "loginName", loginName)
+		return nil, ErrLoginNameExist
+	}
+
+	password, hash, err := initialPassword()
+	if err != nil {
+		logs.Error("[accessControl::ProvisionTeacher] generate password failed", "err", err)
+		return nil, err
+	}
+
+	l := &LoginInfo{
+		UserType:   base.AccountTypeTeacher,
+		ID:         teacherID,
+		LoginName:  loginName,
+		Password:   hash,
+		Status:     base.AccountStatusActive,
+		MustChange: true,
+	}
+	err = ac.db.InsertPassword(l)
+	if err != nil {
+		logs.Warn("[accessControl::ProvisionTeacher] InsertPassword failed", "err", err)
+		return nil, err
+	}
+	ac.loginMap[key] = l
+
+	logs.Info("[accessControl::ProvisionTeacher] account created", "teacherID", teacherID, "loginName", loginName)
+	return &Credential{TeacherID: teacherID, LoginName: loginName, Password: password}, nil
+}
+
+// Reserved check to see if the login name is granted admin by config
+func (ac *accessControl) Reserved(loginName string) bool {
+	return ac.admins[strings.TrimSpace(loginName)]
+}
+
+// ReissueTeacher issue a new initial password to the teacher and sign out
+// all sessions, the account is created if missing and enabled if disabled
+func (ac *accessControl) ReissueTeacher(teacherID int64) (*Credential, error) {
+	if !Tm.IsExist(teacherID) {
+		return nil, errNotExist
+	}
+
+	ac.loginMutex.Lock()
+	l := ac.findAccount(base.AccountTypeTeacher, teacherID)
+	if l == nil {
+		ac.loginMutex.Unlock()
+		return ac.ProvisionTeacher(teacherID, "")
+	}
+	defer ac.loginMutex.Unlock()
+
+	password, hash, err := initialPassword()
+	if err != nil {
+		logs.Error("[accessControl::ReissueTeacher] generate password failed", "err", err)
+		return nil, err
+	}
+
+	if l.Status == base.AccountStatusDisabled {
+		err = ac.db.UpdateAccountStatus(l.ID, l.UserType, base.AccountStatusActive)
+		if err != nil {
+			logs.Warn("[accessControl::ReissueTeacher] UpdateAccountStatus failed", "err", err)
+			return nil, err
+		}
+		l.Status = base.AccountStatusActive
+	}
+
+	err = ac.db.UpdatePassword(l.ID, l.UserType, hash, true)
+	if err != nil {
+		logs.Warn("[accessControl::ReissueTeacher] UpdatePassword failed", "err", err)
+		return nil, err
+	}
+	l.Password = hash
+	l.MustChange = true
+
+	count := ac.revoke(func(v *LoginInfo) bool {
+		return v.UserType == l.UserType && v.LoginName == l.LoginName
+	})
+	logs.Info("[accessControl::ReissueTeacher] credential reissued", "teacherID", teacherID, "revoked", count)
+	return &Credential{TeacherID: teacherID, LoginName: l.LoginName, Password: password}, nil
+}
+
+// DisableTeachers disable accounts of teachers deleted and sign them out,
+// teachers still exist are skipped
+func (ac *accessControl) DisableTeachers(idList []int64) {
+	ac.loginMutex.Lock()
+	defer ac.loginMutex.Unlock()
+
+	for _, id := range idList {
+		if Tm.IsExist(id) {
+			continue
+		}
+
+		l := ac.findAccount(base.AccountTypeTeacher, id)
+		if l == nil || l.Status == base.AccountStatusDisabled {
+			continue
+		}
+
+		err := ac.db.UpdateAccountStatus(l.ID, l.UserType, base.AccountStatusDisabled)
+		if err != nil {
+			logs.Warn("[accessControl::DisableTeachers] UpdateAccountStatus failed", "teacherID", id, "err", err)
+			continue
+		}
+		l.Status = base.AccountStatusDisabled
+
+		count := ac.revoke(func(v *LoginInfo) bool {
+			return v.UserType == l.UserType && v.LoginName == l.LoginName
+		})
+		logs.Info("[accessControl::DisableTeachers] account disabled", "teacherID", id, "revoked", count)
+	}
+}
+
+// checkRename check to see if the student could take loginName, nil if the
+// student never logged in
+func (ac *accessControl) checkRename(studentID int64, loginName string) error {
+	ac.loginMutex.RLock()
+	defer ac.loginMutex.RUnlock()
+
+	l := ac.findAccount(base.AccountTypeStudent, studentID)
+	if l == nil || l.LoginName == loginName {
+		return nil
+	}
+	if _, ok := ac.loginMap[LoginKey{UserType: base.AccountTypeStudent, LoginName: loginName}]; ok {
+		return ErrLoginNameExist
+	}
+	return nil
+}
+
+// RenameStudent change login name of the student after the register number
+// changed, sessions are signed out. nothing to do if never logged in
+func (ac *accessControl) RenameStudent(studentID int64, loginName string) error {
+	ac.loginMutex.Lock()
+	defer ac.loginMutex.Unlock()
+
+	l := ac.findAccount(base.AccountTypeStudent, studentID)
+	if l == nil || l.LoginName == loginName {
+		return nil
+	}
+
+	key := LoginKey{UserType: base.AccountTypeStudent, LoginName: loginName}
+	if _, ok := ac.loginMap[key]; ok {
+		logs.Warn("[accessControl::RenameStudent] login name exist", "loginName", loginName)
+		return ErrLoginNameExist
+	}
+
+	err := ac.db.UpdateLoginName(l.ID, l.UserType, loginName)
+	if err != nil {
+		logs.Warn("[accessControl::RenameStudent] UpdateLoginName failed", "err", err)
+		return err
+	}
+
+	count := ac.revoke(func(v *LoginInfo) bool {
+		return v.UserType == l.UserType && v.LoginName == l.LoginName
+	})
+	delete(ac.loginMap, LoginKey{UserType: l.UserType, LoginName: l.LoginName})
+	l.LoginName = loginName
+	ac.loginMap[key] = l
+
+	logs.Info("[accessControl::RenameStudent] login name changed", "studentID", studentID, "loginName", loginName, "revoked", count)
+	return nil
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+
+	"github.com/arong/dean/base"
+)
+
+func TestAccessControl_ProvisionTeacher(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db, closer := newTokenStore(t)
+	defer closer()
+
+	Tm.Init(append(TeacherList{}, teachers...))
+	mockStore := NewMockPasswordStore(mockCtrl)
+	ac := accessControl{db: mockStore, store: db, tokenMap: make(map[string]*LoginInfo), loginMap: make(map[LoginKey]*LoginInfo)}
+	ac.SetLifetime(defaultIdleTimeout, defaultMaxLifetime)
+
+	if _, err := ac.ProvisionTeacher(9, ""); err == nil {
+		t.Fatal("account created for unknown teacher")
+	}
+
+	mockStore.EXPECT().InsertPassword(gomock.Any()).Return(errors.New("sank your ship"))
+	if _, err := ac.ProvisionTeacher(1, ""); err == nil || len(ac.loginMap) != 0 {
+		t.Fatal("logic error", err)
+	}
+
+	mockStore.EXPECT().InsertPassword(gomock.Any()).Return(nil)
+	cred, err := ac.ProvisionTeacher(1, "")
+	if err != nil || cred.LoginName != "赵一" || len(cred.Password) != initialPasswordLength {
+		t.Fatal("provision failed", cred, err)
+	}
+	if _, err = ac.ProvisionTeacher(1, "zhao"); err != errExist {
+		t.Fatal("account created twice", err)
+	}
+	if _, err = ac.ProvisionTeacher(2, "赵一"); err != ErrLoginNameExist {
+		t.Fatal("login name taken", err)
+	}
+	ac.SetAdmins([]string{"admin"})
+	if _, err = ac.ProvisionTeacher(2, " admin "); err != ErrLoginNameReserved {
+		t.Fatal("admin name taken", err)
+	}
+
+	// first login asks for a new password
+	key := LoginKey{UserType: base.AccountTypeTeacher, LoginName: cred.LoginName}
+	ret, err := ac.Login(&LoginRequest{LoginKey: key, Password: clientDigest(cred.Password)})
+	if err != nil || !ret.MustChange {
+		t.Fatal("login failed", ret, err)
+	}
+
+	if err = ac.Update(&UpdateRequest{LoginKey: key, Password: clientDigest(cred.Password), CurrentToken: ret.Token}); err != ErrSamePassword {
+		t.Fatal("initial password kept", err)
+	}
+
+	mockStore.EXPECT().UpdatePassword(int64(1), base.AccountTypeTeacher, gomock.Any(), false).Return(nil)
+	err = ac.Update(&UpdateRequest{LoginKey: key, Password: clientDigest("new"), CurrentToken: ret.Token})
+	if err != nil {
+		t.Fatal("update failed", err)
+	}
+	if l, ok := ac.VerifyToken(ret.Token); !ok || l.MustChange {
+		t.Fatal("session still restricted")
+	}
+
+	// still exist, nothing to do
+	ac.DisableTeachers([]int64{1})
+
+	Tm.Init(append(TeacherList{}, teachers[1:]...))
+	mockStore.EXPECT().UpdateAccountStatus(int64(1), base.AccountTypeTeacher, base.AccountStatusDisabled).Return(nil)
+	ac.DisableTeachers([]int64{1})
+	if _, ok := ac.VerifyToken(ret.Token); ok {
+		t.Fatal("session of disabled account alive")
+	}
+	if _, err = ac.Login(&LoginRequest{LoginKey: key, Password: clientDigest("new")}); err != ErrDisabled {
+		t.Fatal("disabled account logged in", err)
+	}
+	if _, err = ac.ReissueTeacher(1); err != errNotExist {
+		t.Fatal("reissued for deleted teacher", err)
+	}
+}
+
+func TestAccessControl_ReissueTeacher(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	Tm.Init(append(TeacherList{}, teachers...))
+	key := LoginKey{UserType: base.AccountTypeTeacher, LoginName: "qian"}
+	mockStore := NewMockPasswordStore(mockCtrl)
+	ac := accessControl{db: mockStore, tokenMap: make(map[string]*LoginInfo), loginMap: map[LoginKey]*LoginInfo{
+		key: {ID: 2, UserType: base.AccountTypeTeacher, LoginName: "qian", Password: "old", Status: base.AccountStatusDisabled},
+	}}
+
+	gomock.InOrder(
+		mockStore.EXPECT().UpdateAccountStatus(int64(2), base.AccountTypeTeacher, base.AccountStatusActive).Return(nil),
+		mockStore.EXPECT().UpdatePassword(int64(2), base.AccountTypeTeacher, gomock.Any(), true).Return(nil),
+	)
+	cred, err := ac.ReissueTeacher(2)
+	l := ac.loginMap[key]
+	if err != nil || cred.LoginName != "qian" || l.Status != base.AccountStatusActive || !l.MustChange ||
+		!verifyPassword(l.Password, clientDigest(cred.Password)) {
+		t.Fatal("reissue failed", cred, err)
+	}
+
+	// created if missing
+	mockStore.EXPECT().InsertPassword(gomock.Any()).Return(nil)
+	cred, err = ac.ReissueTeacher(3)
+	if err != nil || cred.LoginName != "孙三" || ac.findAccount(base.AccountTypeTeacher, 3) == nil {
+		t.Fatal("account not created", cred, err)
+	}
+}
@@ -35,9 +35,17 @@ var (
 	ErrClassNotExist = errors.New("class not exist")
 )
 
+//go:generate mockgen -destination=./mock_class.go -source=class.go ClassStore
+type ClassStore interface {
+	InsertClass(*Class) error
+	UpdateClass(*Class) error
+	DeleteClass(int) error
+}
+
 type classManager struct {
 	idMap map[int]*Class
 	mutex sync.Mutex
+	store ClassStore
 }
 
 //func (cm *classManager) Lock() {
@@ -48,6 +56,11 @@ type classManager struct {
 //	cm.mutex.Unlock()
 //}
 
+// SetStore set storage of class
+func (cm *classManager) SetStore(s ClassStore) {
+	cm.store = s
+}
+
 // Init maintain the relation between class and teacher
 func (cm *classManager) Init(data map[int]*Class) {
 	if cm == nil {
@@ -78,9 +91,9 @@ func (cm *classManager) AddClass(c *Class) (int, error) {
 		c.Name = prefix + chineseNumberMap[c.Grade] + chineseNumberMap[c.Index] + suffix
 	}
 
-	err := Ma.InsertClass(c)
+	err := cm.store.InsertClass(c)
 	if err != nil {
-		logs.Warn("database error")
+		logs.Warn("database error", "err", err)
 		return ret, err
 	}
 
@@ -111,28 +124,32 @@ func (cm *classManager) ModifyClass(r *Class) error {
 		return nil
 	}
 
+	// work on a copy, cache is updated only after the change is committed
+	tmp := *curr
 	if r.Term != 0 {
-		curr.Term = r.Term
+		tmp.Term = r.Term
 	}
 
 	if r.MasterID != 0 && r.MasterID != curr.MasterID {
-		curr.MasterID = r.MasterID
+		tmp.MasterID = r.MasterID
 	}
 
 	if r.Year != 0 && r.Year != curr.Year {
-		curr.Year = r.Year
+		tmp.Year = r.Year
 	}
 
 	// diff two list
-	curr.TeacherList, curr.AddList, curr.RemoveList = curr.TeacherList.Diff(r.TeacherList)
-	logs.Debug("[ModifyClass]", "addList", curr.AddList, "delList", curr.RemoveList, "all", curr.TeacherList)
-	err = Ma.UpdateClass(curr)
+	tmp.TeacherList, tmp.AddList, tmp.RemoveList = curr.TeacherList.Diff(r.TeacherList)
+	logs.Debug("[ModifyClass]", "addList", tmp.AddList, "delList", tmp.RemoveList, "all", tmp.TeacherList)
+	err = cm.store.UpdateClass(&tmp)
 	if err != nil {
-		logs.Warn("[ModifyClass] database error")
+		// the diff result is dropped with tmp
+		logs.Warn("[ModifyClass] database error", "err", err)
 		return err
 	}
-	curr.AddList = InstructorList{}
-	curr.RemoveList = InstructorList{}
+	tmp.AddList = InstructorList{}
+	tmp.RemoveList = InstructorList{}
+	*curr = tmp
 	return nil
 }
 
@@ -149,10 +166,11 @@ func (cm *classManager) DelClass(list ClassIDList) (ClassIDList, error) {
 			continue
 		}
 
-		err := Ma.DeleteClass(id)
+		err := cm.store.DeleteClass(id)
 		if err != nil {
 			logs.Warn("[DelClass] database failed", "err", err)
 			failedList = append(failedList, id)
+			continue
 		}
 		delete(cm.idMap, id)
 	}
@@ -183,6 +201,111 @@ func (cm *classManager) GetAll() ItemList {
 	return resp
 }
 
+// gradeClasses id of classes in the grade
+func (cm *classManager) gradeClasses(grade int) []int {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	ret := []int{}
+	for _, v := range cm.idMap {
+		if v.Grade == grade {
+			ret = append(ret, v.ID)
+		}
+	}
+	return ret
+}
+
+// IsMaster check to see if the teacher is head teacher of any class
+func (cm *classManager) IsMaster(teacherID int64) bool {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	for _, v := range cm.idMap {
+		if v.MasterID == teacherID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInstructor check to see if the teacher teaches the subject in the class
+func (cm *classManager) IsInstructor(classID, subjectID int, teacherID int64) bool {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	c, ok := cm.idMap[classID]
+	if !ok {
+		return false
+	}
+	for _, v := range c.TeacherList {
+		if v.TeacherID == teacherID && v.SubjectID == subjectID {
+			return true
+		}
+	}
+	return false
+}
+
+// InstructorClasses subjects the teacher teaches, by class
+func (cm *classManager) InstructorClasses(teacherID int64) map[int][]int {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	ret := make(map[int][]int)
+	for _, c := range cm.idMap {
+		for _, v := range c.TeacherList {
+			if v.TeacherID == teacherID {
+				ret[c.ID] = append(ret[c.ID], v.SubjectID)
+			}
+		}
+	}
+	return ret
+}
+
+// MasterClasses classes the teacher is head teacher of
+func (cm *classManager) MasterClasses(teacherID int64) ClassList {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	list := ClassList{}
+	for _, v := range cm.idMap {
+		if v.MasterID == teacherID {
+			tmp := *v
+			list = append(list, &tmp)
+		}
+	}
+	sort.Sort(list)
+	return list
+}
+
+// GetMasterClass get class info with names of instructors,
+// only the head teacher of the class is allowed
+func (cm *classManager) GetMasterClass(classID int, teacherID int64) (*Class, error) {
+	cm.mutex.Lock()
+	val, ok := cm.idMap[classID]
+	if !ok {
+		cm.mutex.Unlock()
+		return nil, ErrClassNotExist
+	}
+
+	if teacherID == 0 || val.MasterID != teacherID {
+		cm.mutex.Unlock()
+		logs.Info("[classManager::GetMasterClass] not head teacher", "classID", classID, "teacherID", teacherID)
+		return nil, errPermission
+	}
+
+	ret := *val
+	ret.TeacherList = append(InstructorList{}, val.TeacherList...)
+	cm.mutex.Unlock()
+
+	for k, v := range ret.TeacherList {
+		if t, err := Tm.GetTeacherInfo(v.TeacherID); err == nil {
+			ret.TeacherList[k].Teacher = t.Name
+		}
+		ret.TeacherList[k].Subject = Sm.getSubjectName(v.SubjectID)
+	}
+	return &ret, nil
+}
+
 // GetInfo get class info
 func (cm *classManager) GetInfo(id int) (*Class, error) {
 	ret := &Class{}
@@ -199,3 +322,68 @@ func (cm *classManager) GetInfo(id int) (*Class, error) {
 	*ret = *val
 	return ret, nil
 }
+
+// FindClass class of the grade and index, the latest one if the same grade
+// and index exist in several years
+func (cm *classManager) FindClass(grade, index int) (*Class, error) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	var ret *Class
+	for _, v := range cm.idMap {
+		if v.Grade != grade || v.Index != index {
+			continue
+		}
+		if ret == nil || v.Year > ret.Year {
+			ret = v
+		}
+	}
+	if ret == nil {
+		return nil, ErrClassNotExist
+	}
+
+	tmp := *ret
+	return &tmp, nil
+}
+
+// SetStudents fill student list of classes, called after students are loaded
+func (cm *classManager) SetStudents(students map[int64]*StudentInfo) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	list := make(map[int][]int64)
+	for _, v := range students {
+		list[v.ClassID] = append(list[v.ClassID], v.StudentID)
+	}
+
+	for k, v := range cm.idMap {
+		ids := list[k]
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		v.StudentList = ids
+	}
+}
+
+// moveStudent move student from one class to another, zero means no class.
+// new slices are built since copies from GetInfo share the old ones
+func (cm *classManager) moveStudent(studentID int64, from, to int) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if c, ok := cm.idMap[from]; ok && from != 0 {
+		ids := make([]int64, 0, len(c.StudentList))
+		for _, v := range c.StudentList {
+			if v != studentID {
+				ids = append(ids, v)
+			}
+		}
+		c.StudentList = ids
+	}
+
+	if c, ok := cm.idMap[to]; ok && to != 0 {
+		ids := make([]int64, 0, len(c.StudentList)+1)
+		ids = append(ids, c.StudentList...)
+		ids = append(ids, studentID)
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		c.StudentList = ids
+	}
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+)
+
+func newClass() *Class {
+	c := &Class{ID: 1, Term: 1, MasterID: 1, Name: "高一一班", Year: 2019,
+		TeacherList: InstructorList{{TeacherID: 1, SubjectID: 1}, {TeacherID: 2, SubjectID: 2}}}
+	c.Grade = 1
+	c.Index = 1
+	return c
+}
+
+func TestInstructorList_Diff(t *testing.T) {
+	curr := InstructorList{{TeacherID: 1, SubjectID: 1}, {TeacherID: 2, SubjectID: 2}, {TeacherID: 3, SubjectID: 3}}
+	req := InstructorList{{TeacherID: 1, SubjectID: 1}, {TeacherID: 3, SubjectID: 2}, {TeacherID: 4, SubjectID: 4}}
+
+	all, add, del := curr.Diff(req)
+	if len(all) != 3 || len(add) != 2 || len(del) != 2 {
+		t.Fatal("diff failed", all, add, del)
+	}
+
+	for _, v := range all {
+		if v.SubjectID == 2 && v.TeacherID != 3 {
+			t.Fatal("replaced instructor kept", all)
+		}
+	}
+}
+
+func TestClassManager_ModifyClass(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	Tm.Init(append(TeacherList{}, teachers...))
+
+	mockStore := NewMockClassStore(mockCtrl)
+	cm := classManager{store: mockStore}
+	cm.Init(map[int]*Class{1: newClass()})
+
+	req := newClass()
+	req.Year = 2020
+	req.TeacherList = InstructorList{{TeacherID: 1, SubjectID: 1}, {TeacherID: 3, SubjectID: 2}}
+
+	mockStore.EXPECT().UpdateClass(gomock.Any()).Return(errors.New("sank your ship"))
+	err := cm.ModifyClass(req)
+	if err == nil {
+		t.Fatal("logic error")
+	}
+
+	// nothing changed on failure
+	curr, _ := cm.GetInfo(1)
+	if curr.Year != 2019 || !curr.Equal(*newClass()) || len(curr.AddList) != 0 || len(curr.RemoveList) != 0 {
+		t.Fatal("cache changed on failure", curr)
+	}
+
+	mockStore.EXPECT().UpdateClass(gomock.Any()).DoAndReturn(func(c *Class) error {
+		if len(c.AddList) != 1 || len(c.RemoveList) != 1 {
+			t.Fatal("diff result not passed", c.AddList, c.RemoveList)
+		}
+		return nil
+	})
+	err = cm.ModifyClass(req)
+	if err != nil {
+		t.Fatal("modify class failed", err)
+	}
+
+	curr, _ = cm.GetInfo(1)
+	if curr.Year != 2020 || len(curr.TeacherList) != 2 || len(curr.AddList) != 0 {
+		t.Fatal("cache not updated", curr)
+	}
+}
+
+func TestClassManager_GetMasterClass(t *testing.T) {
+	Tm.Init(append(TeacherList{}, teachers...))
+
+	other := newClass()
+	other.ID = 2
+	other.Index = 2
+	other.MasterID = 2
+	cm := classManager{}
+	cm.Init(map[int]*Class{1: newClass(), 2: other})
+
+	list := cm.MasterClasses(1)
+	if len(list) != 1 || list[0].ID != 1 || len(cm.MasterClasses(3)) != 0 {
+		t.Fatal("master classes mismatch", list)
+	}
+
+	_, err := cm.GetMasterClass(2, 1)
+	if err != errPermission {
+		t.Fatal("class of other teacher returned", err)
+	}
+	_, err = cm.GetMasterClass(3, 1)
+	if err != ErrClassNotExist {
+		t.Fatal("unknown class returned", err)
+	}
+
+	c, err := cm.GetMasterClass(1, 1)
+	if err != nil || len(c.TeacherList) != 2 || c.TeacherList[1].Teacher != "钱二" {
+		t.Fatal("get master class failed", c, err)
+	}
+
+	// cache not touched
+	if curr, _ := cm.GetInfo(1); curr.TeacherList[1].Teacher != "" {
+		t.Fatal("cache changed", curr.TeacherList)
+	}
+}
+
+func TestClassManager_AddDelClass(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := NewMockClassStore(mockCtrl)
+	cm := classManager{store: mockStore}
+	cm.Init(nil)
+
+	mockStore.EXPECT().InsertClass(gomock.Any()).Return(errors.New("sank your ship"))
+	_, err := cm.AddClass(newClass())
+	if err == nil || len(cm.GetAll()) != 0 {
+		t.Fatal("logic error")
+	}
+
+	mockStore.EXPECT().InsertClass(gomock.Any()).Return(nil)
+	id, err := cm.AddClass(newClass())
+	if err != nil || id != 1 {
+		t.Fatal("add class failed", err)
+	}
+
+	mockStore.EXPECT().DeleteClass(1).Return(errors.New("sank your ship"))
+	failed, _ := cm.DelClass(ClassIDList{1})
+	if len(failed) != 1 {
+		t.Fatal("logic error")
+	}
+
+	if _, err = cm.GetInfo(1); err != nil {
+		t.Fatal("class removed on failure")
+	}
+}
@@ -7,4 +7,28 @@ var (
 	errExist        = errors.New("resource exist")
 	errPermission   = errors.New("permission denied")
 	errInvalidInput = errors.New("invalid input")
+	errSubmitted    = errors.New("already submitted")
+	errStatus       = errors.New("status not allowed")
+	errNoQuestion   = errors.New("no question")
+
+	// ErrNotOpen questionnaire not started yet
+	ErrNotOpen = errors.New("questionnaire not open yet")
+	// ErrClosed questionnaire already stopped
+	ErrClosed = errors.New("questionnaire closed")
+	// ErrLoginNameExist login name taken by another account
+	ErrLoginNameExist = errors.New("login name exist")
+	// ErrLoginNameReserved login name granted admin by config
+	ErrLoginNameReserved = errors.New("login name reserved")
+	// ErrDisabled account disabled
+	ErrDisabled = errors.New("account disabled")
+	// ErrDefaultPassword default password is not set or not allowed
+	ErrDefaultPassword = errors.New("default password not allowed")
+	// ErrSamePassword new password is the same as the old one
+	ErrSamePassword = errors.New("new password should be different")
+	// ErrRegisterExist register number taken by another student
+	ErrRegisterExist = errors.New("register number exist")
+	// ErrNotInstructor teacher is not assigned to the subject in the class
+	ErrNotInstructor = errors.New("not instructor of the subject in the class")
+	// ErrLocked too many failed login, the account or ip is locked for a while
+	ErrLocked = errors.New("too many failures, try again later")
 )
@@ -0,0 +1,258 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/pkg/errors"
+)
+
+// columns of the student sheet, header is matched in either language
+const (
+	colRegister = iota
+	colName
+	colGender
+	colGrade
+	colClass
+	colBirthday
+	colAddress
+	colMobile
+	colCount
+)
+
+var studentColumns = map[string]int{
+	"学号": colRegister, "register_id": colRegister,
+	"姓名": colName, "name": colName,
+	"性别": colGender, "gender": colGender,
+	"年级": colGrade, "grade": colGrade,
+	"班级": colClass, "class": colClass, "index": colClass,
+	"出生日期": colBirthday, "生日": colBirthday, "birthday": colBirthday,
+	"地址": colAddress, "address": colAddress,
+	"手机": colMobile, "mobile": colMobile,
+}
+
+var (
+	// ErrSheetHeader required column missing in header
+	ErrSheetHeader = errors.New("register number, name, grade and class columns are required")
+	// ErrScoreHeader register number or score column missing in header
+	ErrScoreHeader = errors.New("register number and score columns are required")
+	errGrade       = errors.New("invalid grade")
+	errIndex       = errors.New("invalid class index")
+)
+
+// ImportRow a data row of the sheet, Row is the row number in the sheet
+type ImportRow struct {
+	Row    int
+	Fields [colCount]string
+}
+
+// ImportError why the row is rejected
+type ImportError struct {
+	Row        int    `json:"row"`
+	RegisterID string `json:"register_id"`
+	Msg        string `json:"msg"`
+}
+
+// ImportResult report of the import, nothing is saved in dry run
+type ImportResult struct {
+	DryRun   bool          `json:"dry_run"`
+	Total    int           `json:"total"`
+	Valid    int           `json:"valid"`
+	Imported int           `json:"imported"`
+	Errors   []ImportError `json:"errors"`
+}
+
+// ParseStudentSheet read students from the csv or xlsx file, the first row
+// is the header, blank rows are skipped
+func ParseStudentSheet(name string, r io.Reader) ([]ImportRow, error) {
+	rows, err := readSheet(name, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrSheetHeader
+	}
+
+	index := [colCount]int{}
+	for k := range index {
+		index[k] = -1
+	}
+	for k, v := range rows[0] {
+		if col, ok := studentColumns[strings.ToLower(strings.TrimSpace(v))]; ok {
+			index[col] = k
+		}
+	}
+	for _, v := range []int{colRegister, colName, colGrade, colClass} {
+		if index[v] < 0 {
+			return nil, ErrSheetHeader
+		}
+	}
+
+	ret := []ImportRow{}
+	for k, line := range rows[1:] {
+		row := ImportRow{Row: k + 2}
+		blank := true
+		for col, i := range index {
+			if i >= 0 && i < len(line) {
+				row.Fields[col] = strings.TrimSpace(line[i])
+				blank = blank && row.Fields[col] == ""
+			}
+		}
+		if !blank {
+			ret = append(ret, row)
+		}
+	}
+	return ret, nil
+}
+
+func parseGender(s string) (int, error) {
+	switch strings.ToLower(s) {
+	case "男", "m", "male", "1":
+		return eGenderMale, nil
+	case "女", "f", "female", "2":
+		return eGenderFemale, nil
+	case "", "未知", "3":
+		return eGenderUnknown, nil
+	}
+	return 0, ErrGender
+}
+
+// student convert the row to student info, class is looked up by grade and index
+func (r ImportRow) student() (*StudentInfo, error) {
+	s := &StudentInfo{
+		RegisterID: r.Fields[colRegister],
+		profile: profile{
+			RealName: r.Fields[colName],
+			Address:  r.Fields[colAddress],
+			Mobile:   r.Fields[colMobile],
+		},
+	}
+
+	var err error
+	s.Gender, err = parseGender(r.Fields[colGender])
+	if err != nil {
+		return nil, err
+	}
+
+	s.Birthday, err = sheetDate(r.Fields[colBirthday])
+	if err != nil {
+		return nil, ErrBirthday
+	}
+
+	grade, err := strconv.Atoi(r.Fields[colGrade])
+	if err != nil || grade <= 0 {
+		return nil, errGrade
+	}
+	index, err := strconv.Atoi(r.Fields[colClass])
+	if err != nil || index <= 0 {
+		return nil, errIndex
+	}
+	c, err := Cm.FindClass(grade, index)
+	if err != nil {
+		return nil, err
+	}
+	s.ClassID = c.ID
+
+	return s, s.Check()
+}
+
+// Import validate every row and save the valid ones in one transaction,
+// rows rejected are reported. nothing is saved in dry run
+func (um *userManager) Import(rows []ImportRow, dryRun bool) (*ImportResult, error) {
+	ret := &ImportResult{DryRun: dryRun, Total: len(rows), Errors: []ImportError{}}
+
+	seen := make(map[string]int)
+	valid := []*StudentInfo{}
+	for _, v := range rows {
+		s, err := v.student()
+		if err == nil {
+			if _, ok := um.uuidMap[s.RegisterID]; ok {
+				err = ErrRegisterExist
+			} else if row, ok := seen[s.RegisterID]; ok {
+				err = fmt.Errorf("register number duplicated with row %d", row)
+			}
+		}
+		if err != nil {
+			ret.Errors = append(ret.Errors, ImportError{Row: v.Row, RegisterID: v.Fields[colRegister], Msg: err.Error()})
+			continue
+		}
+
+		seen[s.RegisterID] = v.Row
+		valid = append(valid, s)
+	}
+	ret.Valid = len(valid)
+
+	if dryRun || len(valid) == 0 {
+		return ret, nil
+	}
+
+	err := um.store.InsertStudents(valid)
+	if err != nil {
+		logs.Warn("[userManager::Import] InsertStudents failed", "err", err)
+		return nil, err
+	}
+
+	um.mutex.Lock()
+	for _, v := range valid {
+		um.idMap[v.StudentID] = v
+		um.uuidMap[v.RegisterID] = v
+	}
+	um.mutex.Unlock()
+
+	for _, v := range valid {
+		Cm.moveStudent(v.StudentID, 0, v.ClassID)
+	}
+	ret.Imported = len(valid)
+
+	logs.Info("[userManager::Import] students imported", "total", ret.Total, "imported", ret.Imported)
+	return ret, nil
+}
+
+// ParseScoreSheet read scores of a subject from the csv or xlsx file, the
+// header has register number and score. score not a number is kept as -1
+// and rejected on validation
+func ParseScoreSheet(name string, r io.Reader) ([]ClassScoreRow, error) {
+	rows, err := readSheet(name, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrScoreHeader
+	}
+
+	register, score := -1, -1
+	for k, v := range rows[0] {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "学号", "register_id":
+			register = k
+		case "分数", "成绩", "score":
+			score = k
+		}
+	}
+	if register < 0 || score < 0 {
+		return nil, ErrScoreHeader
+	}
+
+	ret := []ClassScoreRow{}
+	for k, line := range rows[1:] {
+		row := ClassScoreRow{Row: k + 2, Score: -1}
+		if register < len(line) {
+			row.RegisterID = strings.TrimSpace(line[register])
+		}
+		value := ""
+		if score < len(line) {
+			value = strings.TrimSpace(line[score])
+		}
+		if row.RegisterID == "" && value == "" {
+			continue
+		}
+		if v, err := strconv.Atoi(value); err == nil {
+			row.Score = v
+		}
+		ret = append(ret, row)
+	}
+	return ret, nil
+}
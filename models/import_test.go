@@ -0,0 +1,187 @@
+package models
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+)
+
+const studentCSV = "\xef\xbb\xbf学号,姓名,性别,年级,班级,出生日期\n" +
+	"2019001,赵一,男,1,1,2012-09-01\n" +
+	"\n" +
+	"2019002,钱二,女,1,2,2012/9/1\n" +
+	"2019003,孙三,男,1,1,42979\n" +
+	"2019001,李四,男,1,1,\n" +
+	",周五,男,1,1,\n" +
+	"2019006,吴六,x,1,1,\n"
+
+func newXLSX(t *testing.T) []byte {
+	return xlsxWithSheet(t, `<worksheet><sheetData>`+
+		`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c>`+
+		`<c r="C1" t="inlineStr"><is><t>grade</t></is></c><c r="D1" t="inlineStr"><is><t>class</t></is></c></row>`+
+		`<row r="3"><c r="A3"><v>2019001</v></c><c r="B3" t="s"><v>2</v></c><c r="C3"><v>1</v></c><c r="D3"><v>1</v></c></row>`+
+		`</sheetData></worksheet>`)
+}
+
+func xlsxWithSheet(t *testing.T, sheet string) []byte {
+	files := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="学生" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       `<sst><si><t>学号</t></si><si><t>姓名</t></si><si><r><t>赵</t></r><r><t>一</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml":   sheet,
+	}
+
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for k, v := range files {
+		f, err := w.Create(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(v))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseStudentSheet(t *testing.T) {
+	if _, err := ParseStudentSheet("a.txt", strings.NewReader(studentCSV)); err != ErrSheetFormat {
+		t.Fatal("unsupported format accepted", err)
+	}
+
+	if _, err := ParseStudentSheet("a.csv", strings.NewReader("学号,姓名\n2019001,赵一\n")); err != ErrSheetHeader {
+		t.Fatal("missing column accepted", err)
+	}
+
+	rows, err := ParseStudentSheet("a.csv", strings.NewReader(studentCSV))
+	if err != nil || len(rows) != 6 {
+		t.Fatal("parse csv failed", rows, err)
+	}
+	if rows[1].Row != 4 || rows[1].Fields[colName] != "钱二" || rows[1].Fields[colMobile] != "" {
+		t.Fatal("row number or field mismatch", rows[1])
+	}
+
+	rows, err = ParseStudentSheet("a.xlsx", bytes.NewReader(newXLSX(t)))
+	if err != nil || len(rows) != 1 {
+		t.Fatal("parse xlsx failed", rows, err)
+	}
+	if rows[0].Row != 3 || rows[0].Fields[colRegister] != "2019001" || rows[0].Fields[colName] != "赵一" || rows[0].Fields[colClass] != "1" {
+		t.Fatal("xlsx field mismatch", rows[0])
+	}
+}
+
+func TestReadXLSX_limit(t *testing.T) {
+	// cells out of range are ignored instead of padded
+	rows, err := readSheet("a.xlsx", bytes.NewReader(xlsxWithSheet(t, `<worksheet><sheetData>`+
+		`<row r="1"><c r="A1"><v>1</v></c><c r="ZZZZZZZ1"><v>2</v></c><c r="BM1"><v>3</v></c></row>`+
+		`</sheetData></worksheet>`)))
+	if err != nil || len(rows) != 1 || len(rows[0]) != 1 {
+		t.Fatal("column not limited", len(rows), err)
+	}
+
+	sheet := &bytes.Buffer{}
+	sheet.WriteString(`<worksheet><sheetData>`)
+	for i := 0; i <= maxSheetRows+1; i++ {
+		sheet.WriteString(`<row><c><v>1</v></c></row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+	if _, err = readSheet("a.xlsx", bytes.NewReader(xlsxWithSheet(t, sheet.String()))); err != ErrSheetTooLarge {
+		t.Fatal("row not limited", err)
+	}
+
+	// a worksheet too large after decompressed
+	sheet.Reset()
+	sheet.WriteString(`<worksheet><sheetData><row><c><v>`)
+	sheet.WriteString(strings.Repeat("1", maxXLSXEntry))
+	sheet.WriteString(`</v></c></row></sheetData></worksheet>`)
+	if _, err = readSheet("a.xlsx", bytes.NewReader(xlsxWithSheet(t, sheet.String()))); err != ErrSheetTooLarge {
+		t.Fatal("decompressed size not limited", err)
+	}
+}
+
+func TestUserManager_Import(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	Cm = classManager{idMap: map[int]*Class{
+		1: {ID: 1, Filter: Filter{Grade: 1, Index: 1}, Year: 2018},
+		2: {ID: 2, Filter: Filter{Grade: 1, Index: 1}, Year: 2019},
+		3: {ID: 3, Filter: Filter{Grade: 1, Index: 2}, Year: 2019},
+	}}
+	defer func() { Cm = classManager{} }()
+
+	mockStore := NewMockStudentStore(mockCtrl)
+	um := userManager{store: mockStore}
+	um.Init(map[int64]*StudentInfo{
+		1: {StudentID: 1, RegisterID: "2018001", ClassID: 1},
+	})
+
+	rows, err := ParseStudentSheet("a.csv", strings.NewReader(studentCSV+"2018001,郑七,男,1,1,\n2019008,王八,男,2,1,\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, err := um.Import(rows, true)
+	if err != nil || ret.Total != 8 || ret.Valid != 3 || ret.Imported != 0 || len(ret.Errors) != 5 {
+		t.Fatal("dry run failed", ret, err)
+	}
+	for k, v := range []int{6, 7, 8, 9, 10} {
+		if ret.Errors[k].Row != v {
+			t.Fatal("row number mismatch", ret.Errors)
+		}
+	}
+	if um.IsExist(2) {
+		t.Fatal("saved in dry run")
+	}
+
+	mockStore.EXPECT().InsertStudents(gomock.Any()).Return(errors.New("sank your ship"))
+	if _, err = um.Import(rows, false); err == nil {
+		t.Fatal("logic error")
+	}
+	if _, err = um.GetStudentByRegisterNumber("2019001"); err == nil {
+		t.Fatal("cache changed on failure")
+	}
+
+	mockStore.EXPECT().InsertStudents(gomock.Any()).DoAndReturn(func(list []*StudentInfo) error {
+		for k, v := range list {
+			v.StudentID = int64(k + 2)
+		}
+		return nil
+	})
+	ret, err = um.Import(rows, false)
+	if err != nil || ret.Imported != 3 {
+		t.Fatal("import failed", ret, err)
+	}
+
+	s, err := um.GetStudentByRegisterNumber("2019003")
+	if err != nil || s.ClassID != 2 || s.Gender != eGenderMale || s.Birthday != "2017-09-01" {
+		t.Fatal("student mismatch", s, err)
+	}
+	if c, _ := Cm.GetInfo(2); len(c.StudentList) != 2 {
+		t.Fatal("class student list not synced", c.StudentList)
+	}
+}
+
+func TestParseScoreSheet(t *testing.T) {
+	if _, err := ParseScoreSheet("a.csv", strings.NewReader("学号,姓名\n2019001,赵一\n")); err != ErrScoreHeader {
+		t.Fatal("missing column accepted", err)
+	}
+
+	rows, err := ParseScoreSheet("a.csv", strings.NewReader("学号,姓名,成绩\n2019001,赵一,90\n\n2019002,钱二,缺考\n"))
+	if err != nil || len(rows) != 2 {
+		t.Fatal("parse failed", rows, err)
+	}
+	if rows[0].Row != 2 || rows[0].RegisterID != "2019001" || rows[0].Score != 90 {
+		t.Fatal("row mismatch", rows[0])
+	}
+	if rows[1].Row != 4 || rows[1].Score != -1 {
+		t.Fatal("invalid score not kept", rows[1])
+	}
+}
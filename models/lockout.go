@@ -0,0 +1,330 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/dgraph-io/badger"
+)
+
+const (
+	lockoutPrefix       = "lockout:" // key prefix of lock record in store
+	defaultAccountLimit = 10         // failures of an account within failureWindow
+	defaultIPLimit      = 50         // failures from an ip within failureWindow
+	defaultLockDuration = 30 * time.Minute
+	failureWindow       = time.Hour
+	maxFailureHistory   = 100 // failures kept in each record
+)
+
+// LoginFailure a failed login attempt
+type LoginFailure struct {
+	Time      time.Time `json:"time"`
+	IP        string    `json:"ip,omitempty"`
+	UserType  int       `json:"type,omitempty"`
+	LoginName string    `json:"login_name,omitempty"`
+}
+
+// LockRecord recent failures of an account or an ip
+type LockRecord struct {
+	UserType    int            `json:"type,omitempty"`
+	LoginName   string         `json:"login_name,omitempty"`
+	IP          string         `json:"ip,omitempty"`
+	Failures    []LoginFailure `json:"failures"`
+	LockedUntil time.Time      `json:"locked_until"`
+}
+
+func accountLockKey(key LoginKey) string {
+	return fmt.Sprintf("%saccount:%d:%s", lockoutPrefix, key.UserType, key.LoginName)
+}
+
+func ipLockKey(ip string) string {
+	return lockoutPrefix + "ip:" + ip
+}
+
+// key of the record in store
+func (r *LockRecord) key() string {
+	if r.IP != "" {
+		return ipLockKey(r.IP)
+	}
+	return accountLockKey(LoginKey{UserType: r.UserType, LoginName: r.LoginName})
+}
+
+// Locked check to see if the record is locked at now
+func (r *LockRecord) Locked(now time.Time) bool {
+	return r.LockedUntil.After(now)
+}
+
+// recent count failures within the window and after the last lock
+func (r *LockRecord) recent(now time.Time) int {
+	since := now.Add(-failureWindow)
+	if r.LockedUntil.After(since) {
+		since = r.LockedUntil
+	}
+
+	count := 0
+	for _, v := range r.Failures {
+		if v.Time.After(since) {
+			count++
+		}
+	}
+	return count
+}
+
+// expired nothing worth keeping
+func (r *LockRecord) expired(now time.Time) bool {
+	return !r.Locked(now) && r.recent(now) == 0
+}
+
+type LockRecordList []*LockRecord
+
+func (l LockRecordList) Len() int {
+	return len(l)
+}
+
+func (l LockRecordList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+func (l LockRecordList) Less(i, j int) bool {
+	return l[i].LockedUntil.After(l[j].LockedUntil)
+}
+
+// lockout count failed login of accounts and ip, records are persisted in store.
+// zero value is ready to use with default limits
+type lockout struct {
+	mutex        sync.Mutex
+	store        *badger.DB
+	records      map[string]*LockRecord
+	accountLimit int
+	ipLimit      int
+	duration     time.Duration
+}
+
+// setLimit set failures allowed and lock duration, zero keeps the default
+func (lo *lockout) setLimit(accountLimit, ipLimit int, duration time.Duration) {
+	lo.mutex.Lock()
+	defer lo.mutex.Unlock()
+
+	if accountLimit > 0 && accountLimit <= maxFailureHistory {
+		lo.accountLimit = accountLimit
+	}
+	if ipLimit > 0 && ipLimit <= maxFailureHistory {
+		lo.ipLimit = ipLimit
+	}
+	if duration > 0 {
+		lo.duration = duration
+	}
+}
+
+// limits failures allowed and lock duration in effect, caller holds mutex
+func (lo *lockout) limits() (int, int, time.Duration) {
+	accountLimit, ipLimit, duration := lo.accountLimit, lo.ipLimit, lo.duration
+	if accountLimit == 0 {
+		accountLimit = defaultAccountLimit
+	}
+	if ipLimit == 0 {
+		ipLimit = defaultIPLimit
+	}
+	if duration == 0 {
+		duration = defaultLockDuration
+	}
+	return accountLimit, ipLimit, duration
+}
+
+// load read records in store, expired ones are removed
+func (lo *lockout) load(now time.Time) {
+	lo.mutex.Lock()
+	defer lo.mutex.Unlock()
+
+	lo.records = make(map[string]*LockRecord)
+	expired := []string{}
+	err := lo.store.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(lockoutPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			k := string(item.KeyCopy(nil))
+			r := &LockRecord{}
+			err := item.Value(func(v []byte) error {
+				return json.Unmarshal(v, r)
+			})
+			if err != nil || r.key() != k || r.expired(now) {
+				expired = append(expired, k)
+				continue
+			}
+			lo.records[k] = r
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Error("[lockout::load] load lock record failed", "err", err)
+	}
+
+	for _, v := range expired {
+		lo.remove(v)
+	}
+	logs.Info("[lockout::load] lock record loaded", "count", len(lo.records), "expired", len(expired))
+}
+
+// check return ErrLocked if the account or the ip is locked
+func (lo *lockout) check(key LoginKey, ip string, now time.Time) error {
+	lo.mutex.Lock()
+	defer lo.mutex.Unlock()
+
+	for _, k := range []string{accountLockKey(key), ipLockKey(ip)} {
+		if r, ok := lo.records[k]; ok && r.Locked(now) {
+			logs.Warn("[lockout::check] login rejected", "key", k, "lockedUntil", r.LockedUntil)
+			return ErrLocked
+		}
+	}
+	return nil
+}
+
+// fail record a failed login, lock the account or the ip on too many failures
+func (lo *lockout) fail(key LoginKey, ip string, now time.Time) {
+	lo.mutex.Lock()
+	defer lo.mutex.Unlock()
+
+	if lo.records == nil {
+		lo.records = make(map[string]*LockRecord)
+	}
+
+	accountLimit, ipLimit, duration := lo.limits()
+	failure := LoginFailure{Time: now, IP: ip, UserType: key.UserType, LoginName: key.LoginName}
+	lo.add(&LockRecord{UserType: key.UserType, LoginName: key.LoginName}, failure, accountLimit, duration, now)
+	if ip != "" {
+		lo.add(&LockRecord{IP: ip}, failure, ipLimit, duration, now)
+	}
+}
+
+// add append failure to the record, caller holds mutex
+func (lo *lockout) add(r *LockRecord, failure LoginFailure, limit int, duration time.Duration, now time.Time) {
+	k := r.key()
+	if v, ok := lo.records[k]; ok {
+		r = v
+	} else {
+		lo.records[k] = r
+	}
+
+	r.Failures = append(r.Failures, failure)
+	if len(r.Failures) > maxFailureHistory {
+		r.Failures = r.Failures[len(r.Failures)-maxFailureHistory:]
+	}
+
+	if r.recent(now) >= limit {
+		r.LockedUntil = now.Add(duration)
+		logs.Warn("[lockout::add] locked", "key", k, "lockedUntil", r.LockedUntil)
+	}
+	lo.save(r)
+}
+
+// succeed clear failures of the account after login
+func (lo *lockout) succeed(key LoginKey) {
+	lo.mutex.Lock()
+	defer lo.mutex.Unlock()
+
+	k := accountLockKey(key)
+	if _, ok := lo.records[k]; ok {
+		delete(lo.records, k)
+		lo.remove(k)
+	}
+}
+
+// unlock clear the record of the account or the ip
+func (lo *lockout) unlock(k string) error {
+	lo.mutex.Lock()
+	defer lo.mutex.Unlock()
+
+	if _, ok := lo.records[k]; !ok {
+		return errNotExist
+	}
+	delete(lo.records, k)
+	lo.remove(k)
+	return nil
+}
+
+// list records locked at now, with all failures if all is set
+func (lo *lockout) list(now time.Time, all bool) LockRecordList {
+	lo.mutex.Lock()
+	defer lo.mutex.Unlock()
+
+	ret := LockRecordList{}
+	for _, v := range lo.records {
+		if !all && !v.Locked(now) {
+			continue
+		}
+		tmp := *v
+		tmp.Failures = append([]LoginFailure{}, v.Failures...)
+		ret = append(ret, &tmp)
+	}
+	sort.Sort(ret)
+	return ret
+}
+
+// sweep drop expired records
+func (lo *lockout) sweep(now time.Time) {
+	lo.mutex.Lock()
+	defer lo.mutex.Unlock()
+
+	for k, v := range lo.records {
+		if v.expired(now) {
+			delete(lo.records, k)
+			lo.remove(k)
+		}
+	}
+}
+
+func (lo *lockout) save(r *LockRecord) {
+	if lo.store == nil {
+		return
+	}
+
+	buffer, err := json.Marshal(r)
+	if err != nil {
+		logs.Error("[lockout::save] fatal error", err)
+		return
+	}
+
+	err = lo.store.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(r.key()), buffer)
+	})
+	if err != nil {
+		logs.Error("[lockout::save] failed to set value", "err", err)
+	}
+}
+
+func (lo *lockout) remove(k string) {
+	if lo.store == nil {
+		return
+	}
+
+	err := lo.store.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(k))
+	})
+	if err != nil {
+		logs.Error("[lockout::remove] failed to delete value", "err", err)
+	}
+}
+
+// UnlockRequest unlock an account or an ip
+type UnlockRequest struct {
+	LoginKey
+	IP string `json:"ip"`
+}
+
+func (u UnlockRequest) key() (string, error) {
+	if ip := strings.TrimSpace(u.IP); ip != "" {
+		return ipLockKey(ip), nil
+	}
+	if u.LoginName == "" {
+		return "", errInvalidParam
+	}
+	return accountLockKey(u.LoginKey), nil
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arong/dean/base"
+)
+
+func TestLockout(t *testing.T) {
+	db, closer := newTokenStore(t)
+	defer closer()
+
+	zhao := LoginKey{UserType: base.AccountTypeTeacher, LoginName: "zhao"}
+	qian := LoginKey{UserType: base.AccountTypeTeacher, LoginName: "qian"}
+	now := time.Now()
+
+	lo := lockout{store: db}
+	lo.setLimit(3, 5, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		lo.fail(zhao, "10.0.0.1", now)
+	}
+	if lo.check(zhao, "10.0.0.1", now) != nil || len(lo.list(now, false)) != 0 || len(lo.list(now, true)) != 2 {
+		t.Fatal("locked too early")
+	}
+
+	lo.fail(zhao, "10.0.0.1", now)
+	if lo.check(zhao, "10.0.0.2", now) != ErrLocked || lo.check(qian, "10.0.0.1", now) != nil {
+		t.Fatal("account not locked")
+	}
+
+	// ip is locked whichever account is used
+	lo.fail(qian, "10.0.0.1", now)
+	lo.fail(qian, "10.0.0.1", now)
+	if lo.check(LoginKey{UserType: base.AccountTypeStudent, LoginName: "sun"}, "10.0.0.1", now) != ErrLocked {
+		t.Fatal("ip not locked")
+	}
+
+	// survive restart
+	reload := lockout{store: db}
+	reload.load(now)
+	list := reload.list(now, false)
+	if len(list) != 2 || len(list[0].Failures) != 5 && len(list[1].Failures) != 5 {
+		t.Fatal("lock record not persisted", list)
+	}
+
+	err := reload.unlock(ipLockKey("10.0.0.1"))
+	if err != nil || reload.check(qian, "10.0.0.1", now) != nil || reload.unlock(ipLockKey("10.0.0.1")) != errNotExist {
+		t.Fatal("unlock failed", err)
+	}
+
+	// lock expires, counting starts again after that
+	later := now.Add(2 * time.Minute)
+	if reload.check(zhao, "", later) != nil {
+		t.Fatal("lock not expired")
+	}
+	reload.fail(zhao, "", later)
+	if reload.check(zhao, "", later) != nil {
+		t.Fatal("failures before lock counted again")
+	}
+
+	// success clears the account
+	reload.succeed(zhao)
+	reload.load(now)
+	if list = reload.list(now, true); len(list) != 1 || list[0].LoginName != "qian" {
+		t.Fatal("record not removed", list)
+	}
+
+	// failures out of window are dropped
+	reload.sweep(now.Add(2 * time.Hour))
+	reload.load(now)
+	if len(reload.list(now, true)) != 0 {
+		t.Fatal("expired record not removed")
+	}
+}
+
+func TestAccessControl_LoginLockout(t *testing.T) {
+	db, closer := newTokenStore(t)
+	defer closer()
+
+	hash, _ := hashPassword("secret")
+	key := LoginKey{UserType: base.AccountTypeTeacher, LoginName: "zhao"}
+	ac := accessControl{store: db, tokenMap: make(map[string]*LoginInfo),
+		loginMap: map[LoginKey]*LoginInfo{key: {ID: 1, UserType: base.AccountTypeTeacher, LoginName: "zhao", Password: hash}}}
+	ac.lock.store = db
+	ac.SetLockout(2, 10, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		_, err := ac.Login(&LoginRequest{LoginKey: key, Password: "wrong", IP: "10.0.0.1"})
+		if err != errPermission {
+			t.Fatal("logic error", err)
+		}
+	}
+
+	_, err := ac.Login(&LoginRequest{LoginKey: key, Password: "secret", IP: "10.0.0.2"})
+	if err != ErrLocked {
+		t.Fatal("account not locked", err)
+	}
+
+	// lock records are not tokens
+	ac.tokenMap = make(map[string]*LoginInfo)
+	ac.LoadToken()
+	if len(ac.tokenMap) != 0 || len(ac.Lockouts(false)) != 1 {
+		t.Fatal("lock record mixed with token")
+	}
+
+	err = ac.Unlock(&UnlockRequest{LoginKey: key})
+	if err != nil {
+		t.Fatal("unlock failed", err)
+	}
+	ret, err := ac.Login(&LoginRequest{LoginKey: key, Password: "secret", IP: "10.0.0.2"})
+	if err != nil || ret.Token == "" || len(ac.Lockouts(true)) != 1 {
+		t.Fatal("login failed", err)
+	}
+}
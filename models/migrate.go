@@ -0,0 +1,251 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/arong/dean/base"
+	"github.com/astaxie/beego/logs"
+	"github.com/pkg/errors"
+)
+
+// ErrSchemaOutdated database schema does not match the migrations shipped with the binary
+var ErrSchemaOutdated = errors.New("schema out of date")
+
+// migration file name, example: 0001_init.up.sql
+var migrationName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migration is one numbered schema change
+type migration struct {
+	Version int
+	Name    string
+	Up      string // path of up script
+	Down    string // path of down script
+}
+
+// loadMigrations read migrations under dir, sorted by version
+func loadMigrations(dir string) ([]migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	versionMap := make(map[int]*migration)
+	for _, f := range files {
+		match := migrationName.FindStringSubmatch(f.Name())
+		if f.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		if version <= 0 {
+			return nil, errors.Errorf("invalid migration version: %s", f.Name())
+		}
+
+		m, ok := versionMap[version]
+		if !ok {
+			m = &migration{Version: version, Name: match[2]}
+			versionMap[version] = m
+		} else if m.Name != match[2] {
+			return nil, errors.Errorf("duplicated migration version: %d", version)
+		}
+
+		path := filepath.Join(dir, f.Name())
+		if match[3] == "up" {
+			m.Up = path
+		} else {
+			m.Down = path
+		}
+	}
+
+	list := make([]migration, 0, len(versionMap))
+	for _, v := range versionMap {
+		if v.Up == "" || v.Down == "" {
+			return nil, errors.Errorf("migration %d_%s should have both up and down script", v.Version, v.Name)
+		}
+		list = append(list, *v)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return list, nil
+}
+
+// Migrator apply migrations of a driver and track them in schema_version
+type Migrator struct {
+	db         *sql.DB
+	migrations []migration
+}
+
+// NewMigrator open database and load migrations of the configured driver
+func NewMigrator(conf *DBConfig) (*Migrator, error) {
+	driver := conf.Driver
+	if driver == "" {
+		driver = DriverMySQL
+	}
+
+	list, err := loadMigrations(filepath.Join(conf.Migration, driver))
+	if err != nil {
+		return nil, err
+	}
+
+	source := conf.source()
+	if driver == DriverMySQL {
+		// scripts hold several statements
+		source += "&multiStatements=true"
+	}
+
+	db, err := sql.Open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: list}, nil
+}
+
+// Close close database
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// Latest the newest version shipped
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec("CREATE TABLE IF NOT EXISTS `schema_version` (`iVersion` INTEGER NOT NULL PRIMARY KEY, `vName` VARCHAR(64) NOT NULL DEFAULT '', `dtApplyTime` VARCHAR(19) NOT NULL DEFAULT '');")
+	return err
+}
+
+// Current the version database is at, 0 for an empty database
+func (m *Migrator) Current() (int, error) {
+	err := m.ensureTable()
+	if err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	err = m.db.QueryRow("SELECT MAX(`iVersion`) FROM `schema_version`;").Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// Check return ErrSchemaOutdated if database is not at the latest version
+func (m *Migrator) Check() error {
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+
+	if current != m.Latest() {
+		logs.Warn("[Migrator::Check] schema out of date", "current", current, "latest", m.Latest())
+		return errors.Wrapf(ErrSchemaOutdated, "current %d, latest %d", current, m.Latest())
+	}
+	return nil
+}
+
+// Up apply migrations until version target
+func (m *Migrator) Up(target int) error {
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+
+	if target > m.Latest() {
+		return errors.Errorf("unknown version %d, latest is %d", target, m.Latest())
+	}
+
+	for _, v := range m.migrations {
+		if v.Version <= current || v.Version > target {
+			continue
+		}
+
+		err = m.apply(v.Up, "INSERT INTO `schema_version` (`iVersion`,`vName`,`dtApplyTime`) VALUES (?,?,?);",
+			v.Version, v.Name, time.Now().Format(base.DateTimeFormat))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down roll back migrations until version target
+func (m *Migrator) Down(target int) error {
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+
+	if target < 0 {
+		return errors.Errorf("invalid version %d", target)
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		v := m.migrations[i]
+		if v.Version > current || v.Version <= target {
+			continue
+		}
+
+		err = m.apply(v.Down, "DELETE FROM `schema_version` WHERE `iVersion`=?;", v.Version)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply run script and record the change in one transaction. mysql commits
+// schema changes implicitly, so a failed step there may leave the schema
+// partially applied
+func (m *Migrator) apply(script string, record string, args ...interface{}) error {
+	buff, err := ioutil.ReadFile(script)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(string(buff))
+	if err != nil {
+		logs.Warn("[Migrator::apply] execute script failed", "script", script, "err", err)
+		tx.Rollback()
+		return errors.Wrap(err, filepath.Base(script))
+	}
+
+	_, err = tx.Exec(record, args...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	logs.Info("[Migrator::apply] done", "script", filepath.Base(script))
+	return nil
+}
+
+// String status of the database
+func (m *Migrator) String() string {
+	current, err := m.Current()
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("current version: %d, latest version: %d", current, m.Latest())
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dean")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name string) {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("0002_second.up.sql")
+	write("0002_second.down.sql")
+	write("0001_first.up.sql")
+	write("0001_first.down.sql")
+	write("readme.md")
+
+	list, err := loadMigrations(dir)
+	if err != nil || len(list) != 2 || list[0].Version != 1 || list[1].Name != "second" {
+		t.Fatal("load migrations failed", list, err)
+	}
+
+	write("0003_third.up.sql")
+	_, err = loadMigrations(dir)
+	if err == nil {
+		t.Fatal("load migration without down script success")
+	}
+
+	write("0003_other.down.sql")
+	_, err = loadMigrations(dir)
+	if err == nil {
+		t.Fatal("load duplicated version success")
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dean")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, err := NewMigrator(&DBConfig{Driver: DriverSQLite, Path: filepath.Join(dir, "dean.db"), Migration: "../sql/migrations"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if m.Latest() < 2 {
+		t.Fatal("migrations not loaded")
+	}
+
+	err = m.Check()
+	if errors.Cause(err) != ErrSchemaOutdated {
+		t.Fatal("empty database passed check", err)
+	}
+
+	err = m.Up(1)
+	if err != nil {
+		t.Fatal("migrate up failed", err)
+	}
+
+	current, err := m.Current()
+	if err != nil || current != 1 {
+		t.Fatal("version not recorded", current, err)
+	}
+
+	_, err = m.db.Exec("SELECT vMobile FROM tbStudent;")
+	if err == nil {
+		t.Fatal("column exists before migration")
+	}
+
+	err = m.Up(m.Latest())
+	if err != nil || m.Check() != nil {
+		t.Fatal("migrate to latest failed", err)
+	}
+
+	_, err = m.db.Exec("SELECT vMobile FROM tbStudent;")
+	if err != nil {
+		t.Fatal("column not added", err)
+	}
+
+	err = m.Up(m.Latest() + 1)
+	if err == nil {
+		t.Fatal("migrate to unknown version success")
+	}
+
+	err = m.Down(0)
+	if err != nil {
+		t.Fatal("migrate down failed", err)
+	}
+
+	current, _ = m.Current()
+	if current != 0 {
+		t.Fatal("version not removed", current)
+	}
+
+	_, err = m.db.Exec("SELECT 1 FROM tbStudent;")
+	if err == nil {
+		t.Fatal("table exists after roll back")
+	}
+}
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: class.go
+
+// Package mock_models is a generated GoMock package.
+package models
+
+import (
+	"reflect"
+
+	"github.com/golang/mock/gomock"
+)
+
+// MockClassStore is a mock of ClassStore interface
+type MockClassStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockClassStoreMockRecorder
+}
+
+// MockClassStoreMockRecorder is the mock recorder for MockClassStore
+type MockClassStoreMockRecorder struct {
+	mock *MockClassStore
+}
+
+// NewMockClassStore creates a new mock instance
+func NewMockClassStore(ctrl *gomock.Controller) *MockClassStore {
+	mock := &MockClassStore{ctrl: ctrl}
+	mock.recorder = &MockClassStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockClassStore) EXPECT() *MockClassStoreMockRecorder {
+	return m.recorder
+}
+
+// InsertClass mocks base method
+func (m *MockClassStore) InsertClass(arg0 *Class) error {
+	ret := m.ctrl.Call(m, "InsertClass", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertClass indicates an expected call of InsertClass
+func (mr *MockClassStoreMockRecorder) InsertClass(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertClass", reflect.TypeOf((*MockClassStore)(nil).InsertClass), arg0)
+}
+
+// UpdateClass mocks base method
+func (m *MockClassStore) UpdateClass(arg0 *Class) error {
+	ret := m.ctrl.Call(m, "UpdateClass", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateClass indicates an expected call of UpdateClass
+func (mr *MockClassStoreMockRecorder) UpdateClass(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateClass", reflect.TypeOf((*MockClassStore)(nil).UpdateClass), arg0)
+}
+
+// DeleteClass mocks base method
+func (m *MockClassStore) DeleteClass(arg0 int) error {
+	ret := m.ctrl.Call(m, "DeleteClass", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteClass indicates an expected call of DeleteClass
+func (mr *MockClassStoreMockRecorder) DeleteClass(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteClass", reflect.TypeOf((*MockClassStore)(nil).DeleteClass), arg0)
+}
@@ -0,0 +1,106 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: accessControl.go
+
+// Package mock_models is a generated GoMock package.
+package models
+
+import (
+	"reflect"
+
+	"github.com/golang/mock/gomock"
+)
+
+// MockPasswordStore is a mock of PasswordStore interface
+type MockPasswordStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordStoreMockRecorder
+}
+
+// MockPasswordStoreMockRecorder is the mock recorder for MockPasswordStore
+type MockPasswordStoreMockRecorder struct {
+	mock *MockPasswordStore
+}
+
+// NewMockPasswordStore creates a new mock instance
+func NewMockPasswordStore(ctrl *gomock.Controller) *MockPasswordStore {
+	mock := &MockPasswordStore{ctrl: ctrl}
+	mock.recorder = &MockPasswordStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPasswordStore) EXPECT() *MockPasswordStoreMockRecorder {
+	return m.recorder
+}
+
+// InsertPassword mocks base method
+func (m *MockPasswordStore) InsertPassword(arg0 *LoginInfo) error {
+	ret := m.ctrl.Call(m, "InsertPassword", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertPassword indicates an expected call of InsertPassword
+func (mr *MockPasswordStoreMockRecorder) InsertPassword(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertPassword", reflect.TypeOf((*MockPasswordStore)(nil).InsertPassword), arg0)
+}
+
+// UpdatePassword mocks base method
+func (m *MockPasswordStore) UpdatePassword(id int64, userType int, password string, mustChange bool) error {
+	ret := m.ctrl.Call(m, "UpdatePassword", id, userType, password, mustChange)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword
+func (mr *MockPasswordStoreMockRecorder) UpdatePassword(id, userType, password, mustChange interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockPasswordStore)(nil).UpdatePassword), id, userType, password, mustChange)
+}
+
+// UpdateRole mocks base method
+func (m *MockPasswordStore) UpdateRole(id int64, userType, role int) error {
+	ret := m.ctrl.Call(m, "UpdateRole", id, userType, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRole indicates an expected call of UpdateRole
+func (mr *MockPasswordStoreMockRecorder) UpdateRole(id, userType, role interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRole", reflect.TypeOf((*MockPasswordStore)(nil).UpdateRole), id, userType, role)
+}
+
+// UpdateAccountStatus mocks base method
+func (m *MockPasswordStore) UpdateAccountStatus(id int64, userType, status int) error {
+	ret := m.ctrl.Call(m, "UpdateAccountStatus", id, userType, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAccountStatus indicates an expected call of UpdateAccountStatus
+func (mr *MockPasswordStoreMockRecorder) UpdateAccountStatus(id, userType, status interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountStatus", reflect.TypeOf((*MockPasswordStore)(nil).UpdateAccountStatus), id, userType, status)
+}
+
+// UpdateLoginName mocks base method
+func (m *MockPasswordStore) UpdateLoginName(id int64, userType int, loginName string) error {
+	ret := m.ctrl.Call(m, "UpdateLoginName", id, userType, loginName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLoginName indicates an expected call of UpdateLoginName
+func (mr *MockPasswordStoreMockRecorder) UpdateLoginName(id, userType, loginName interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLoginName", reflect.TypeOf((*MockPasswordStore)(nil).UpdateLoginName), id, userType, loginName)
+}
+
+// ResetAllPassword mocks base method
+func (m *MockPasswordStore) ResetAllPassword(arg0 string) error {
+	ret := m.ctrl.Call(m, "ResetAllPassword", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetAllPassword indicates an expected call of ResetAllPassword
+func (mr *MockPasswordStoreMockRecorder) ResetAllPassword(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetAllPassword", reflect.TypeOf((*MockPasswordStore)(nil).ResetAllPassword), arg0)
+}
@@ -0,0 +1,144 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: questionnaire.go
+
+// Package mock_models is a generated GoMock package.
+package models
+
+import (
+	"reflect"
+
+	"github.com/golang/mock/gomock"
+)
+
+// MockQuestionnaireStore is a mock of QuestionnaireStore interface
+type MockQuestionnaireStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuestionnaireStoreMockRecorder
+}
+
+// MockQuestionnaireStoreMockRecorder is the mock recorder for MockQuestionnaireStore
+type MockQuestionnaireStoreMockRecorder struct {
+	mock *MockQuestionnaireStore
+}
+
+// NewMockQuestionnaireStore creates a new mock instance
+func NewMockQuestionnaireStore(ctrl *gomock.Controller) *MockQuestionnaireStore {
+	mock := &MockQuestionnaireStore{ctrl: ctrl}
+	mock.recorder = &MockQuestionnaireStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockQuestionnaireStore) EXPECT() *MockQuestionnaireStoreMockRecorder {
+	return m.recorder
+}
+
+// AddQuestionnaire mocks base method
+func (m *MockQuestionnaireStore) AddQuestionnaire(arg0 *QuestionnaireInfo) error {
+	ret := m.ctrl.Call(m, "AddQuestionnaire", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddQuestionnaire indicates an expected call of AddQuestionnaire
+func (mr *MockQuestionnaireStoreMockRecorder) AddQuestionnaire(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddQuestionnaire", reflect.TypeOf((*MockQuestionnaireStore)(nil).AddQuestionnaire), arg0)
+}
+
+// UpdateQuestionnaire mocks base method
+func (m *MockQuestionnaireStore) UpdateQuestionnaire(arg0 *QuestionnaireInfo) error {
+	ret := m.ctrl.Call(m, "UpdateQuestionnaire", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateQuestionnaire indicates an expected call of UpdateQuestionnaire
+func (mr *MockQuestionnaireStoreMockRecorder) UpdateQuestionnaire(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateQuestionnaire", reflect.TypeOf((*MockQuestionnaireStore)(nil).UpdateQuestionnaire), arg0)
+}
+
+// UpdateQuestionnaireStatus mocks base method
+func (m *MockQuestionnaireStore) UpdateQuestionnaireStatus(id, from, to int) error {
+	ret := m.ctrl.Call(m, "UpdateQuestionnaireStatus", id, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateQuestionnaireStatus indicates an expected call of UpdateQuestionnaireStatus
+func (mr *MockQuestionnaireStoreMockRecorder) UpdateQuestionnaireStatus(id, from, to interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateQuestionnaireStatus", reflect.TypeOf((*MockQuestionnaireStore)(nil).UpdateQuestionnaireStatus), id, from, to)
+}
+
+// DeleteQuestionnaire mocks base method
+func (m *MockQuestionnaireStore) DeleteQuestionnaire(arg0 int) error {
+	ret := m.ctrl.Call(m, "DeleteQuestionnaire", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteQuestionnaire indicates an expected call of DeleteQuestionnaire
+func (mr *MockQuestionnaireStoreMockRecorder) DeleteQuestionnaire(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteQuestionnaire", reflect.TypeOf((*MockQuestionnaireStore)(nil).DeleteQuestionnaire), arg0)
+}
+
+// AddQuestion mocks base method
+func (m *MockQuestionnaireStore) AddQuestion(arg0 int, arg1 *QuestionInfo) (int, error) {
+	ret := m.ctrl.Call(m, "AddQuestion", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddQuestion indicates an expected call of AddQuestion
+func (mr *MockQuestionnaireStoreMockRecorder) AddQuestion(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddQuestion", reflect.TypeOf((*MockQuestionnaireStore)(nil).AddQuestion), arg0, arg1)
+}
+
+// UpdateQuestion mocks base method
+func (m *MockQuestionnaireStore) UpdateQuestion(arg0 *QuestionInfo) (int, error) {
+	ret := m.ctrl.Call(m, "UpdateQuestion", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateQuestion indicates an expected call of UpdateQuestion
+func (mr *MockQuestionnaireStoreMockRecorder) UpdateQuestion(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateQuestion", reflect.TypeOf((*MockQuestionnaireStore)(nil).UpdateQuestion), arg0)
+}
+
+// DeleteQuestion mocks base method
+func (m *MockQuestionnaireStore) DeleteQuestion(arg0 int) error {
+	ret := m.ctrl.Call(m, "DeleteQuestion", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteQuestion indicates an expected call of DeleteQuestion
+func (mr *MockQuestionnaireStoreMockRecorder) DeleteQuestion(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteQuestion", reflect.TypeOf((*MockQuestionnaireStore)(nil).DeleteQuestion), arg0)
+}
+
+// InsertSubmission mocks base method
+func (m *MockQuestionnaireStore) InsertSubmission(arg0 QuestionnaireSubmit, arg1 sourceMeta) error {
+	ret := m.ctrl.Call(m, "InsertSubmission", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertSubmission indicates an expected call of InsertSubmission
+func (mr *MockQuestionnaireStoreMockRecorder) InsertSubmission(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertSubmission", reflect.TypeOf((*MockQuestionnaireStore)(nil).InsertSubmission), arg0, arg1)
+}
+
+// ReplaceSubmission mocks base method
+func (m *MockQuestionnaireStore) ReplaceSubmission(arg0 QuestionnaireSubmit, arg1 sourceMeta) error {
+	ret := m.ctrl.Call(m, "ReplaceSubmission", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceSubmission indicates an expected call of ReplaceSubmission
+func (mr *MockQuestionnaireStoreMockRecorder) ReplaceSubmission(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceSubmission", reflect.TypeOf((*MockQuestionnaireStore)(nil).ReplaceSubmission), arg0, arg1)
+}
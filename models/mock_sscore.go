@@ -0,0 +1,46 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: sscore.go
+
+// Package mock_models is a generated GoMock package.
+package models
+
+import (
+	"reflect"
+
+	"github.com/golang/mock/gomock"
+)
+
+// MockScoreStore is a mock of ScoreStore interface
+type MockScoreStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockScoreStoreMockRecorder
+}
+
+// MockScoreStoreMockRecorder is the mock recorder for MockScoreStore
+type MockScoreStoreMockRecorder struct {
+	mock *MockScoreStore
+}
+
+// NewMockScoreStore creates a new mock instance
+func NewMockScoreStore(ctrl *gomock.Controller) *MockScoreStore {
+	mock := &MockScoreStore{ctrl: ctrl}
+	mock.recorder = &MockScoreStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockScoreStore) EXPECT() *MockScoreStoreMockRecorder {
+	return m.recorder
+}
+
+// UpsertScores mocks base method
+func (m *MockScoreStore) UpsertScores(arg0 StudentScoreList) error {
+	ret := m.ctrl.Call(m, "UpsertScores", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertScores indicates an expected call of UpsertScores
+func (mr *MockScoreStoreMockRecorder) UpsertScores(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertScores", reflect.TypeOf((*MockScoreStore)(nil).UpsertScores), arg0)
+}
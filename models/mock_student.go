@@ -0,0 +1,83 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: student.go
+
+// Package mock_models is a generated GoMock package.
+package models
+
+import (
+	"reflect"
+
+	"github.com/golang/mock/gomock"
+)
+
+// MockStudentStore is a mock of StudentStore interface
+type MockStudentStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStudentStoreMockRecorder
+}
+
+// MockStudentStoreMockRecorder is the mock recorder for MockStudentStore
+type MockStudentStoreMockRecorder struct {
+	mock *MockStudentStore
+}
+
+// NewMockStudentStore creates a new mock instance
+func NewMockStudentStore(ctrl *gomock.Controller) *MockStudentStore {
+	mock := &MockStudentStore{ctrl: ctrl}
+	mock.recorder = &MockStudentStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockStudentStore) EXPECT() *MockStudentStoreMockRecorder {
+	return m.recorder
+}
+
+// InsertStudent mocks base method
+func (m *MockStudentStore) InsertStudent(arg0 *StudentInfo) (int64, error) {
+	ret := m.ctrl.Call(m, "InsertStudent", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertStudent indicates an expected call of InsertStudent
+func (mr *MockStudentStoreMockRecorder) InsertStudent(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertStudent", reflect.TypeOf((*MockStudentStore)(nil).InsertStudent), arg0)
+}
+
+// InsertStudents mocks base method
+func (m *MockStudentStore) InsertStudents(arg0 []*StudentInfo) error {
+	ret := m.ctrl.Call(m, "InsertStudents", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertStudents indicates an expected call of InsertStudents
+func (mr *MockStudentStoreMockRecorder) InsertStudents(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertStudents", reflect.TypeOf((*MockStudentStore)(nil).InsertStudents), arg0)
+}
+
+// UpdateStudent mocks base method
+func (m *MockStudentStore) UpdateStudent(arg0 *StudentInfo) error {
+	ret := m.ctrl.Call(m, "UpdateStudent", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStudent indicates an expected call of UpdateStudent
+func (mr *MockStudentStoreMockRecorder) UpdateStudent(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStudent", reflect.TypeOf((*MockStudentStore)(nil).UpdateStudent), arg0)
+}
+
+// DeleteStudent mocks base method
+func (m *MockStudentStore) DeleteStudent(arg0 int64) error {
+	ret := m.ctrl.Call(m, "DeleteStudent", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteStudent indicates an expected call of DeleteStudent
+func (mr *MockStudentStoreMockRecorder) DeleteStudent(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStudent", reflect.TypeOf((*MockStudentStore)(nil).DeleteStudent), arg0)
+}
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: teacher.go
+
+// Package mock_models is a generated GoMock package.
+package models
+
+import (
+	"reflect"
+
+	"github.com/golang/mock/gomock"
+)
+
+// MockTeacherStore is a mock of TeacherStore interface
+type MockTeacherStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockTeacherStoreMockRecorder
+}
+
+// MockTeacherStoreMockRecorder is the mock recorder for MockTeacherStore
+type MockTeacherStoreMockRecorder struct {
+	mock *MockTeacherStore
+}
+
+// NewMockTeacherStore creates a new mock instance
+func NewMockTeacherStore(ctrl *gomock.Controller) *MockTeacherStore {
+	mock := &MockTeacherStore{ctrl: ctrl}
+	mock.recorder = &MockTeacherStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTeacherStore) EXPECT() *MockTeacherStoreMockRecorder {
+	return m.recorder
+}
+
+// InsertTeacher mocks base method
+func (m *MockTeacherStore) InsertTeacher(arg0 Teacher) (int64, error) {
+	ret := m.ctrl.Call(m, "InsertTeacher", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertTeacher indicates an expected call of InsertTeacher
+func (mr *MockTeacherStoreMockRecorder) InsertTeacher(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertTeacher", reflect.TypeOf((*MockTeacherStore)(nil).InsertTeacher), arg0)
+}
+
+// UpdateTeacher mocks base method
+func (m *MockTeacherStore) UpdateTeacher(arg0 Teacher) error {
+	ret := m.ctrl.Call(m, "UpdateTeacher", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTeacher indicates an expected call of UpdateTeacher
+func (mr *MockTeacherStoreMockRecorder) UpdateTeacher(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTeacher", reflect.TypeOf((*MockTeacherStore)(nil).UpdateTeacher), arg0)
+}
+
+// DeleteTeacher mocks base method
+func (m *MockTeacherStore) DeleteTeacher(arg0 []int64) error {
+	ret := m.ctrl.Call(m, "DeleteTeacher", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTeacher indicates an expected call of DeleteTeacher
+func (mr *MockTeacherStoreMockRecorder) DeleteTeacher(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTeacher", reflect.TypeOf((*MockTeacherStore)(nil).DeleteTeacher), arg0)
+}
@@ -21,6 +21,7 @@ var (
 	ErrBirthday = errors.New("invalid birthday")
 	errAddress  = errors.New("invalid address")
 	errMobile   = errors.New("invalid mobile number")
+	errRegister = errors.New("invalid register number")
 	errSubject  = errors.New("invalid subject id")
 )
 
@@ -29,22 +30,28 @@ var (
  */
 
 type DBConfig struct {
-	User     string `yaml:"user"`
-	Password string `yaml:"password"`
-	Host     string `yaml:"host"`
-	Port     int    `yaml:"port"`
-	DBName   string `yaml:"DBName"`
+	Driver    string `yaml:"driver"`
+	User      string `yaml:"user"`
+	Password  string `yaml:"password"`
+	Host      string `yaml:"host"`
+	Port      int    `yaml:"port"`
+	DBName    string `yaml:"DBName"`
+	Path      string `yaml:"path"`      // sqlite database file
+	Migration string `yaml:"migration"` // migration directory
 }
 
 // GetConf get config from app.conf
 func (c *DBConfig) GetConf() error {
+	c.Driver = beego.AppConfig.DefaultString("driver", DriverMySQL)
 	c.User = beego.AppConfig.String("user")
 	c.Password = beego.AppConfig.String("password")
 	c.Host = beego.AppConfig.String("host")
 	c.Port, _ = beego.AppConfig.Int("port")
 	c.DBName = beego.AppConfig.String("dbName")
+	c.Path = beego.AppConfig.DefaultString("sqlitePath", "./dean.db")
+	c.Migration = beego.AppConfig.DefaultString("migrationDir", "./sql/migrations")
 
-	logs.Debug(*c)
+	logs.Debug("[DBConfig::GetConf]", "driver", c.Driver, "host", c.Host, "port", c.Port, "db", c.DBName, "path", c.Path)
 	return nil
 }
 
@@ -272,9 +279,14 @@ func (il InstructorList) Diff(r InstructorList) (all, add, del InstructorList) {
 		}
 	}
 
+	// keep the unchanged ones, replaced ones are in del
+	replaced := make(map[int]bool)
+	for _, v := range del {
+		replaced[v.SubjectID] = true
+	}
 	for _, i := range il {
 		v := i
-		if _, ok := curr[v.SubjectID]; ok {
+		if _, ok := curr[v.SubjectID]; ok || replaced[v.SubjectID] {
 			continue
 		}
 		all = append(all, v)
@@ -433,6 +445,28 @@ func (q QuestionInfo) Check() error {
 	return err
 }
 
+// inScope check to see if the question apply to subject
+func (q QuestionInfo) inScope(subjectID int) bool {
+	if len(q.Scope) == 0 {
+		return true
+	}
+	for _, v := range q.Scope {
+		if v == subjectID {
+			return true
+		}
+	}
+	return false
+}
+
+func (q QuestionInfo) hasOption(index int) bool {
+	for _, v := range q.Options {
+		if v.Index == index {
+			return true
+		}
+	}
+	return false
+}
+
 type QuestionList []*QuestionInfo
 
 func (q QuestionList) Len() int {
@@ -478,6 +512,7 @@ type QuestionnaireInfo struct {
 	Label           string       `json:"label"`
 	Questions       QuestionList `json:"questions"`
 	Editor          string       `json:"editor"`
+	AllowAmend      bool         `json:"allow_amend"` // student could amend answers before stop time
 	startTime       time.Time
 	stopTime        time.Time
 }
@@ -493,14 +528,14 @@ func (q *QuestionnaireInfo) Check() error {
 
 	var err error
 	if q.StartTime != "" {
-		q.startTime, err = time.Parse(base.DateTimeFormat, q.StartTime)
+		q.startTime, err = base.ParseDateTime(q.StartTime)
 		if err != nil {
 			return errors.New("invalid start time format")
 		}
 	}
 
 	if q.StopTime != "" {
-		q.stopTime, err = time.Parse(base.DateTimeFormat, q.StopTime)
+		q.stopTime, err = base.ParseDateTime(q.StopTime)
 		if err != nil {
 			return errors.New("invalid stop time format")
 		}
@@ -519,11 +554,34 @@ func (q *QuestionnaireInfo) Check() error {
 	return err
 }
 
+// checkOpen check to see if student could answer the questionnaire at now
+func (q *QuestionnaireInfo) checkOpen(now time.Time) error {
+	switch q.Status {
+	case QStatusPublished:
+	case QStatusScheduled:
+		return ErrNotOpen
+	case QStatusExpired:
+		return ErrClosed
+	default:
+		return errStatus
+	}
+
+	if !q.startTime.IsZero() && now.Before(q.startTime) {
+		return ErrNotOpen
+	}
+
+	if !q.stopTime.IsZero() && !now.Before(q.stopTime) {
+		return ErrClosed
+	}
+	return nil
+}
+
 func (q QuestionnaireInfo) Equal(r QuestionnaireInfo) bool {
 	if q.Status != r.Status ||
 		q.Title != r.Title ||
 		q.StartTime != r.StartTime ||
-		q.StopTime != r.StopTime {
+		q.StopTime != r.StopTime ||
+		q.AllowAmend != r.AllowAmend {
 		return false
 	}
 	return true
@@ -578,10 +636,19 @@ const (
 	// questionnaire status
 	QStatusDraft     = 1 // draft, could be edited
 	QStatusPublished = 2 // published, could not be edited
-	QStatusDrawBack  = 3
-	QStatusExpired   = 4
+	QStatusDrawBack  = 3 // withdrawn by editor, not visible to student
+	QStatusExpired   = 4 // stop time reached, could not be changed any more
+	QStatusScheduled = 5 // published, wait for start time
 )
 
+// qStatusTransition list allowed next status of each status
+var qStatusTransition = map[int][]int{
+	QStatusDraft:     {QStatusScheduled, QStatusPublished},
+	QStatusScheduled: {QStatusPublished, QStatusDrawBack, QStatusExpired},
+	QStatusPublished: {QStatusDrawBack, QStatusExpired},
+	QStatusDrawBack:  {QStatusScheduled, QStatusPublished, QStatusExpired},
+}
+
 type AnswerInfo struct {
 	QuestionID int
 	Answer     interface{}
@@ -619,16 +686,37 @@ type TeacherAnswer struct {
 }
 type TeacherAnswerList []TeacherAnswer
 
+type submitKey struct {
+	QuestionnaireID int
+	StudentID       int64
+}
+
+// submission is the answers of a student which already counted in teacher score
+type submission struct {
+	source  sourceMeta
+	answers TeacherAnswerList
+}
+
 type QuestionnaireSubmit struct {
 	QuestionnaireID int
 	StudentID       int64
 	TeacherAnswers  TeacherAnswerList
 }
 
+// Check each teacher is answered once at most, or the teacher would be scored
+// more than once by a single submit
 func (q QuestionnaireSubmit) Check() error {
 	if q.QuestionnaireID == 0 {
 		return errNotExist
 	}
+
+	tmp := make(map[int64]bool)
+	for _, v := range q.TeacherAnswers {
+		if tmp[v.TeacherID] {
+			return errExist
+		}
+		tmp[v.TeacherID] = true
+	}
 	return nil
 }
 
@@ -678,8 +766,12 @@ func (ss StudentScore) Check() error {
 		}
 	}
 
-	if ss.TermID == 0 || ss.Exam == 0 {
-		return errors.New("invalid score")
+	if _, _, err := splitTermID(ss.TermID); err != nil {
+		return err
+	}
+
+	if ss.Exam <= 0 {
+		return errors.New("invalid exam")
 	}
 
 	for _, v := range ss.Scores {
@@ -718,6 +810,37 @@ type ScoreFilter struct {
 	ClassID     int   // 班级
 	StudentID   int64 // 学生ID
 	TermID      int   // 学期
+	Exam        int   // 考试
+	Grade       int   // 年级
+}
+
+// RankItem score of a student in an exam with class rank and grade rank
+type RankItem struct {
+	StudentID  int64         `json:"student_id"`
+	RegisterID string        `json:"register_id"`
+	Name       string        `json:"name"`
+	ClassID    int           `json:"class_id"`
+	Total      int           `json:"total"`
+	Scores     ScorePairList `json:"scores"`
+	ClassRank  int           `json:"class_rank"`
+	GradeRank  int           `json:"grade_rank"`
+}
+
+type RankList []RankItem
+
+func (rl RankList) Len() int {
+	return len(rl)
+}
+
+func (rl RankList) Swap(i, j int) {
+	rl[i], rl[j] = rl[j], rl[i]
+}
+
+func (rl RankList) Less(i, j int) bool {
+	if rl[i].Total != rl[j].Total {
+		return rl[i].Total > rl[j].Total
+	}
+	return rl[i].StudentID < rl[j].StudentID
 }
 
 type StudentInfo struct {
@@ -726,6 +849,51 @@ type StudentInfo struct {
 	StudentID  int64  `json:"student_id"`
 	RegisterID string `json:"register_id"` // 学号
 }
+
+// Check validate student info, age is derived from birthday
+func (s *StudentInfo) Check() error {
+	if s.Gender < eGenderMale || s.Gender > eGenderUnknown {
+		return ErrGender
+	}
+
+	s.RealName = strings.TrimSpace(s.RealName)
+	if s.RealName == "" || utf8.RuneCountInString(s.RealName) > 16 {
+		return ErrName
+	}
+
+	s.RegisterID = strings.TrimSpace(s.RegisterID)
+	if s.RegisterID == "" || len(s.RegisterID) > 16 {
+		return errRegister
+	}
+
+	if len(s.Mobile) > 11 {
+		return errMobile
+	}
+
+	if len(s.Birthday) > 10 {
+		return ErrBirthday
+	}
+
+	if s.Birthday != "" {
+		birth, err := time.Parse("2006-01-02", s.Birthday)
+		if err != nil {
+			return ErrBirthday
+		}
+		s.Age = age.Age(birth)
+	}
+
+	if utf8.RuneCountInString(s.Address) > 64 {
+		return errAddress
+	}
+
+	if s.ClassID != 0 {
+		if _, err := Cm.GetInfo(s.ClassID); err != nil {
+			return ErrClassNotExist
+		}
+	}
+	return nil
+}
+
 type studentList []*StudentInfo
 
 func (cl studentList) Len() int {
@@ -935,15 +1103,99 @@ type ScoreInfo struct {
 // analyzer for choice or selection type
 type TeacherScore struct {
 	Average float64
-	Total   int
-	Count   int
-	Meta    map[int]sourceList // option and its count
-	Remark  []string           // remark for teacher
+	Total   int                    // student count
+	Count   int                    // choice count
+	Meta    map[int]*questionScore // question id -> option and its count
+	Remark  []string               // remark for teacher
+}
+
+type questionScore struct {
+	Count   int                // answer count
+	Options map[int]sourceList // option and its source
+}
+
+type scoreKey struct {
+	QuestionnaireID int
+	TeacherID       int64
+}
+
+// TeacherReport is evaluation of a teacher in single questionnaire
+type TeacherReport struct {
+	QuestionnaireID int              `json:"questionnaire_id"`
+	Title           string           `json:"title"`
+	TeacherID       int64            `json:"teacher_id"`
+	TeacherName     string           `json:"teacher_name"`
+	Total           int              `json:"total"`   // student count
+	Average         float64          `json:"average"` // average of single selection questions
+	Questions       []QuestionReport `json:"questions"`
+	Remarks         []string         `json:"remarks"`
+	Sources         []SourceReport   `json:"sources"` // respondent count by class
+}
+
+type QuestionReport struct {
+	QuestionID int            `json:"id"`
+	Index      int            `json:"index"`
+	Type       int            `json:"type"`
+	Question   string         `json:"question"`
+	Count      int            `json:"count"`   // answer count
+	Average    float64        `json:"average"` // weighted by option index
+	Options    []OptionReport `json:"options"`
+	Sources    []SourceReport `json:"sources"`
+}
+
+type OptionReport struct {
+	Index   int     `json:"index"`
+	Option  string  `json:"option"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+type SourceReport struct {
+	Grade   int     `json:"grade"`
+	Index   int     `json:"index"`
+	Count   int     `json:"count"`
+	Average float64 `json:"average,omitempty"`
+}
+
+// ResponseRate submission of a class in single questionnaire
+type ResponseRate struct {
+	QuestionnaireID int     `json:"questionnaire_id"`
+	Title           string  `json:"title"`
+	ClassID         int     `json:"class_id"`
+	Total           int     `json:"total"`     // student count
+	Submitted       int     `json:"submitted"` // student already submitted
+	Rate            float64 `json:"rate"`
+	Pending         []int64 `json:"pending"` // student not submitted yet
+}
+
+type SourceReportList []SourceReport
+
+func (sl SourceReportList) Len() int {
+	return len(sl)
+}
+func (sl SourceReportList) Swap(i, j int) {
+	sl[i], sl[j] = sl[j], sl[i]
+}
+func (sl SourceReportList) Less(i, j int) bool {
+	if sl[i].Grade != sl[j].Grade {
+		return sl[i].Grade < sl[j].Grade
+	}
+	return sl[i].Index < sl[j].Index
 }
 
 type sourceMeta Filter
 type sourceList []sourceMeta
 
+// remove drop the first item equal to s
+func (sm sourceList) remove(s sourceMeta) sourceList {
+	for k, v := range sm {
+		if v == s {
+			return append(sm[:k], sm[k+1:]...)
+		}
+	}
+	return sm
+}
+
 func (sm sourceList) Len() int {
 	return len(sm)
 }
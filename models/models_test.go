@@ -1,7 +1,12 @@
 package models
 
 import (
+	"math"
 	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
 )
 
 func TestQuestionnaireInfo_IsSame(t *testing.T) {
@@ -46,3 +51,227 @@ func TestQuestionnaireInfo_IsSame(t *testing.T) {
 //		}
 //	}
 //}
+
+func TestQuestionnaireInfo_checkAnswers(t *testing.T) {
+	q := QuestionnaireInfo{
+		Questions: QuestionList{
+			&QuestionInfo{QuestionID: 1, Type: QuestionTypeSingleSelection, Required: true, Options: OptionList{{Index: 1, Option: "A"}, {Index: 2, Option: "B"}}},
+			&QuestionInfo{QuestionID: 2, Type: QuestionTypeMultiSelection, Options: OptionList{{Index: 1, Option: "A"}, {Index: 2, Option: "B"}}},
+			&QuestionInfo{QuestionID: 3, Type: QuestionTypeText, Scope: []int{81}},
+		},
+	}
+
+	in := []struct {
+		answers   AnswerList
+		subjectID int
+		ok        bool
+	}{
+		{answers: AnswerList{{QuestionID: 1, Answer: float64(1)}}, subjectID: 82, ok: true},
+		{answers: AnswerList{{QuestionID: 1, Answer: float64(2)}, {QuestionID: 2, Answer: []interface{}{float64(1), float64(2)}}}, subjectID: 82, ok: true},
+		{answers: AnswerList{{QuestionID: 1, Answer: float64(1)}, {QuestionID: 3, Answer: "good"}}, subjectID: 81, ok: true},
+		{answers: AnswerList{{QuestionID: 2, Answer: []interface{}{float64(1)}}}, subjectID: 82, ok: false},                   // required missing
+		{answers: AnswerList{{QuestionID: 1, Answer: float64(3)}}, subjectID: 82, ok: false},                                  // option not exist
+		{answers: AnswerList{{QuestionID: 1, Answer: "A"}}, subjectID: 82, ok: false},                                         // type mismatch
+		{answers: AnswerList{{QuestionID: 1, Answer: float64(1)}, {QuestionID: 3, Answer: "good"}}, subjectID: 82, ok: false}, // out of scope
+		{answers: AnswerList{{QuestionID: 1, Answer: float64(1)}, {QuestionID: 4, Answer: "good"}}, subjectID: 82, ok: false}, // unknown question
+	}
+
+	for k, v := range in {
+		err := q.checkAnswers(v.answers, v.subjectID)
+		if (err == nil) != v.ok {
+			t.Fatalf("%d check failed, err=%v", k, err)
+		}
+	}
+}
+
+func TestQuestionnaireManager_removeScore(t *testing.T) {
+	qm := questionnaireManager{score: make(map[scoreKey]*TeacherScore)}
+	q := &QuestionnaireInfo{
+		QuestionnaireID: 1,
+		Questions: QuestionList{
+			&QuestionInfo{QuestionID: 1, Type: QuestionTypeSingleSelection},
+			&QuestionInfo{QuestionID: 2, Type: QuestionTypeMultiSelection},
+			&QuestionInfo{QuestionID: 3, Type: QuestionTypeText},
+		},
+	}
+	source := sourceMeta{Grade: 1, Index: 2}
+	first := TeacherAnswer{TeacherID: 1, Answers: AnswerList{
+		{QuestionID: 1, Answer: float64(1)},
+		{QuestionID: 2, Answer: []interface{}{float64(1), float64(2)}},
+		{QuestionID: 3, Answer: "good"},
+	}}
+	second := TeacherAnswer{TeacherID: 1, Answers: AnswerList{
+		{QuestionID: 1, Answer: float64(2)},
+	}}
+
+	qm.addScore(q, first, source)
+	qm.addScore(q, second, source)
+	qm.removeScore(q, first, source)
+
+	s := qm.score[scoreKey{QuestionnaireID: 1, TeacherID: 1}]
+	if s.Total != 1 || s.Count != 1 || len(s.Meta[1].Options[1]) != 0 || len(s.Meta[1].Options[2]) != 1 ||
+		s.Meta[2].Count != 0 || len(s.Remark) != 0 {
+		t.Fatalf("removeScore failed, score=%+v", s)
+	}
+}
+
+func TestQuestionnaireManager_Report(t *testing.T) {
+	qm := questionnaireManager{
+		questionnaires: make(map[int]*QuestionnaireInfo),
+		score:          make(map[scoreKey]*TeacherScore),
+		submitted:      make(map[submitKey]*submission),
+	}
+	q := &QuestionnaireInfo{
+		QuestionnaireID: 1,
+		Questions: QuestionList{
+			&QuestionInfo{QuestionID: 1, Index: 1, Type: QuestionTypeSingleSelection, Options: OptionList{{Index: 1, Option: "A"}, {Index: 2, Option: "B"}}},
+			&QuestionInfo{QuestionID: 2, Index: 2, Type: QuestionTypeText},
+			&QuestionInfo{QuestionID: 3, Index: 3, Type: QuestionTypeMultiSelection, Options: OptionList{{Index: 1, Option: "A"}, {Index: 2, Option: "B"}}},
+		},
+	}
+	qm.questionnaires[1] = q
+
+	qm.restore(q, 1, TeacherAnswer{TeacherID: 1, Answers: AnswerList{{QuestionID: 1, Answer: float64(1)}, {QuestionID: 2, Answer: "good"}}}, sourceMeta{Grade: 1, Index: 1})
+	qm.restore(q, 2, TeacherAnswer{TeacherID: 1, Answers: AnswerList{{QuestionID: 1, Answer: float64(2)}, {QuestionID: 3, Answer: []interface{}{float64(1), float64(2)}}}}, sourceMeta{Grade: 1, Index: 2})
+	qm.restore(q, 3, TeacherAnswer{TeacherID: 1, Answers: AnswerList{{QuestionID: 1, Answer: float64(2)}, {QuestionID: 3, Answer: []interface{}{float64(2)}}}}, sourceMeta{Grade: 1, Index: 2})
+	qm.restore(q, 4, TeacherAnswer{TeacherID: 2, Answers: AnswerList{{QuestionID: 1, Answer: float64(1)}}}, sourceMeta{Grade: 1, Index: 2})
+
+	r, err := qm.Report(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Total != 3 || len(r.Questions) != 2 || len(r.Remarks) != 1 || len(r.Sources) != 2 {
+		t.Fatalf("unexpected report %+v", r)
+	}
+
+	// respondents of each class, not choices
+	if r.Sources[0].Count != 1 || r.Sources[1].Count != 2 {
+		t.Fatalf("unexpected respondents %+v", r.Sources)
+	}
+
+	qr := r.Questions[0]
+	if qr.Count != 3 || qr.Options[0].Count != 1 || qr.Options[1].Count != 2 {
+		t.Fatalf("unexpected option count %+v", qr)
+	}
+
+	if math.Abs(qr.Average-5.0/3) > 1e-9 || math.Abs(r.Average-qr.Average) > 1e-9 {
+		t.Fatalf("unexpected average %v", qr.Average)
+	}
+
+	if qr.Sources[1].Count != 2 || qr.Sources[1].Average != 2 {
+		t.Fatalf("unexpected source %+v", qr.Sources)
+	}
+
+	if _, err = qm.Report(2, 1); err == nil {
+		t.Fatal("questionnaire not exist")
+	}
+}
+
+func TestQuestionnaireManager_ResponseRate(t *testing.T) {
+	qm := questionnaireManager{
+		questionnaires: map[int]*QuestionnaireInfo{1: {QuestionnaireID: 1, Title: "期中评教"}},
+		submitted:      make(map[submitKey]*submission),
+	}
+	qm.submitted[submitKey{QuestionnaireID: 1, StudentID: 1}] = &submission{}
+	qm.submitted[submitKey{QuestionnaireID: 1, StudentID: 3}] = &submission{}
+
+	Um.Init(map[int64]*StudentInfo{
+		1: {StudentID: 1, ClassID: 1},
+		2: {StudentID: 2, ClassID: 1},
+		3: {StudentID: 3, ClassID: 2},
+	})
+	defer Um.Init(nil)
+
+	r, err := qm.ResponseRate(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Total != 2 || r.Submitted != 1 || r.Rate != 0.5 || len(r.Pending) != 1 || r.Pending[0] != 2 {
+		t.Fatalf("unexpected response rate %+v", r)
+	}
+
+	if _, err = qm.ResponseRate(2, 1); err == nil {
+		t.Fatal("questionnaire not exist")
+	}
+}
+
+func TestQuestionnaireInfo_checkOpen(t *testing.T) {
+	now := time.Now()
+	in := []struct {
+		q   QuestionnaireInfo
+		err error
+	}{
+		{q: QuestionnaireInfo{Status: QStatusPublished}, err: nil},
+		{q: QuestionnaireInfo{Status: QStatusPublished, startTime: now.Add(-time.Hour), stopTime: now.Add(time.Hour)}, err: nil},
+		{q: QuestionnaireInfo{Status: QStatusPublished, startTime: now.Add(time.Hour), stopTime: now.Add(2 * time.Hour)}, err: ErrNotOpen},
+		{q: QuestionnaireInfo{Status: QStatusPublished, startTime: now.Add(-2 * time.Hour), stopTime: now.Add(-time.Hour)}, err: ErrClosed},
+		{q: QuestionnaireInfo{Status: QStatusScheduled}, err: ErrNotOpen},
+		{q: QuestionnaireInfo{Status: QStatusExpired}, err: ErrClosed},
+		{q: QuestionnaireInfo{Status: QStatusDraft}, err: errStatus},
+		{q: QuestionnaireInfo{Status: QStatusDrawBack}, err: errStatus},
+	}
+
+	for k, v := range in {
+		err := v.q.checkOpen(now)
+		if err != v.err {
+			t.Fatalf("%d check failed, err=%v", k, err)
+		}
+	}
+}
+
+func TestQuestionnaireManager_AddUpdate(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := NewMockQuestionnaireStore(mockCtrl)
+	qm := questionnaireManager{
+		questionnaires: make(map[int]*QuestionnaireInfo),
+		titleMap:       make(map[string]*QuestionnaireInfo),
+		store:          mockStore,
+	}
+
+	// published on creation is saved as draft
+	mockStore.EXPECT().AddQuestionnaire(gomock.Any()).DoAndReturn(func(q *QuestionnaireInfo) error {
+		q.QuestionnaireID = 1
+		return nil
+	})
+	info := &QuestionnaireInfo{Title: "期中评教", Status: QStatusPublished, StopTime: "2030-01-01 00:00:00"}
+	if _, err := qm.Add(info); err != nil || qm.questionnaires[1].Status != QStatusDraft {
+		t.Fatal("add failed", info, err)
+	}
+
+	// nothing changed if the time is invalid
+	req := *info
+	req.Title = "期末评教"
+	req.StopTime = "2030"
+	if err := qm.Update(&req); err == nil || info.Title != "期中评教" {
+		t.Fatal("invalid update applied", info, err)
+	}
+
+	req.StopTime = "2030-06-01 00:00:00"
+	mockStore.EXPECT().UpdateQuestionnaire(gomock.Any()).Return(errors.New("sank your ship"))
+	if err := qm.Update(&req); err == nil || info.Title != "期中评教" || info.StopTime != "2030-01-01 00:00:00" {
+		t.Fatal("update applied on failure", info, err)
+	}
+
+	mockStore.EXPECT().UpdateQuestionnaire(gomock.Any()).Return(nil)
+	if err := qm.Update(&req); err != nil || info.Title != "期末评教" || qm.titleMap["期末评教"] != info {
+		t.Fatal("update failed", info, err)
+	}
+	if _, ok := qm.titleMap["期中评教"]; ok {
+		t.Fatal("previous title kept")
+	}
+}
+
+func TestQuestionnaireSubmit_Check(t *testing.T) {
+	req := QuestionnaireSubmit{QuestionnaireID: 1, StudentID: 1, TeacherAnswers: TeacherAnswerList{{TeacherID: 1}, {TeacherID: 2}}}
+	if err := req.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	req.TeacherAnswers = append(req.TeacherAnswers, TeacherAnswer{TeacherID: 1})
+	if err := req.Check(); err != errExist {
+		t.Fatal("duplicated teacher accepted", err)
+	}
+}
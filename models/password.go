@@ -0,0 +1,68 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"math/big"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash is compared against when the account does not exist, so the
+// response time does not tell whether a login name is valid
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dean"), bcrypt.DefaultCost)
+
+// hashPassword salt and hash password with bcrypt
+func hashPassword(password string) (string, error) {
+	buff, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(buff), nil
+}
+
+// isHashed check to see if stored is a bcrypt hash, rows created before
+// hashing was introduced hold the plaintext
+func isHashed(stored string) bool {
+	_, err := bcrypt.Cost([]byte(stored))
+	return err == nil
+}
+
+// verifyPassword compare password with the stored one in constant time
+func verifyPassword(stored, password string) bool {
+	if stored == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+
+	if !isHashed(stored) {
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+}
+
+// initial password avoids characters easily mistaken for each other
+const passwordAlphabet = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// randomPassword generate an initial password handed to the user
+func randomPassword(length int) (string, error) {
+	buff := make([]byte, length)
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	for i := range buff {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		buff[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(buff), nil
+}
+
+// clientDigest what the client sends for password typed by the user,
+// the hex encoded sha256 of it
+func clientDigest(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
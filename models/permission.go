@@ -0,0 +1,173 @@
+package models
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/arong/dean/base"
+	"github.com/astaxie/beego/logs"
+	"github.com/pkg/errors"
+)
+
+// Pm global permission table
+var Pm permissionTable
+
+// roleNames name of roles used in the permission file
+var roleNames = map[string]int{
+	"admin":           base.RoleAdmin,
+	"dean":            base.RoleDeanOffice,
+	"head_teacher":    base.RoleHeadTeacher,
+	"subject_teacher": base.RoleSubjectTeacher,
+	"student":         base.RoleStudent,
+}
+
+const (
+	roleStaff = base.RoleAdmin | base.RoleDeanOffice | base.RoleHeadTeacher | base.RoleSubjectTeacher
+	roleDean  = base.RoleAdmin | base.RoleDeanOffice
+	roleAll   = roleStaff | base.RoleStudent
+)
+
+// defaultPermission roles allowed of each action, action is method and router pattern
+var defaultPermission = map[string]int{
+	"POST /api/v1/auth/update":        roleAll,
+	"POST /api/v1/auth/logout":        roleAll,
+	"POST /api/v1/auth/reset":         roleDean,
+	"POST /api/v1/auth/reset/student": roleDean,
+	"POST /api/v1/auth/revoke":        base.RoleAdmin,
+	"POST /api/v1/auth/role":          base.RoleAdmin,
+
+	"GET /api/v1/auth/lockout/list":    base.RoleAdmin,
+	"POST /api/v1/auth/lockout/unlock": base.RoleAdmin,
+
+	"POST /api/v1/dean/class/add":    roleDean,
+	"POST /api/v1/dean/class/update": roleDean,
+	"POST /api/v1/dean/class/delete": roleDean,
+	"GET /api/v1/dean/class/info":    roleStaff,
+	"GET /api/v1/dean/class/filter":  roleStaff,
+	"GET /api/v1/dean/class/list":    roleStaff,
+
+	"POST /api/v1/dean/questionnaire/question/add":    roleDean,
+	"POST /api/v1/dean/questionnaire/question/update": roleDean,
+	"POST /api/v1/dean/questionnaire/question/delete": roleDean,
+	"POST /api/v1/dean/questionnaire/question/filter": roleDean,
+	"POST /api/v1/dean/questionnaire/question/info":   roleDean,
+	"GET /api/v1/dean/questionnaire/view/:teacherID":  roleDean,
+	"POST /api/v1/dean/questionnaire/edit/add":        roleDean,
+	"POST /api/v1/dean/questionnaire/edit/update":     roleDean,
+	"POST /api/v1/dean/questionnaire/edit/delete":     roleDean,
+	"POST /api/v1/dean/questionnaire/edit/publish":    roleDean,
+	"POST /api/v1/dean/questionnaire/edit/withdraw":   roleDean,
+	"POST /api/v1/dean/questionnaire/edit/expire":     roleDean,
+	"POST /api/v1/dean/questionnaire/edit/filter":     roleDean,
+	"POST /api/v1/dean/questionnaire/edit/submit":     base.RoleStudent,
+	"POST /api/v1/dean/questionnaire/vote/survey":     base.RoleStudent,
+	"POST /api/v1/dean/questionnaire/vote/submit":     base.RoleStudent,
+
+	"GET /api/v1/dean/master/class/list":     base.RoleHeadTeacher,
+	"GET /api/v1/dean/master/class/students": base.RoleHeadTeacher,
+	"GET /api/v1/dean/master/class/teachers": base.RoleHeadTeacher,
+	"GET /api/v1/dean/master/class/scores":   base.RoleHeadTeacher,
+	"GET /api/v1/dean/master/class/response": base.RoleHeadTeacher,
+
+	"POST /api/v1/dean/student/add":    roleDean,
+	"POST /api/v1/dean/student/import": roleDean,
+	"POST /api/v1/dean/student/list":   roleStaff,
+	"GET /api/v1/dean/student/info":    roleStaff,
+	"POST /api/v1/dean/student/update": roleDean,
+	"POST /api/v1/dean/student/delete": roleDean,
+
+	"POST /api/v1/dean/subject/add":    roleDean,
+	"POST /api/v1/dean/subject/update": roleDean,
+	"POST /api/v1/dean/subject/delete": roleDean,
+	"GET /api/v1/dean/subject/list":    roleStaff,
+
+	"POST /api/v1/dean/teacher/add":            roleDean,
+	"POST /api/v1/dean/teacher/modify":         roleDean,
+	"GET /api/v1/dean/teacher/info/:teacherID": roleStaff,
+	"POST /api/v1/dean/teacher/filter":         roleStaff,
+	"GET /api/v1/dean/teacher/list":            roleStaff,
+	"POST /api/v1/dean/teacher/delete":         roleDean,
+	"POST /api/v1/dean/teacher/reissue":        base.RoleAdmin,
+
+	"POST /api/v1/student/score/add":          roleStaff,
+	"POST /api/v1/student/score/class":        roleStaff,
+	"POST /api/v1/student/score/class/import": roleStaff,
+	"POST /api/v1/student/score/list":         roleAll,
+	"POST /api/v1/student/score/rank":         roleDean | base.RoleHeadTeacher,
+	"POST /api/v1/student/score/stat":         roleStaff,
+	"GET /api/v1/student/score/trend":         roleDean | base.RoleHeadTeacher | base.RoleStudent,
+	"POST /api/v1/student/vote/survey":        base.RoleStudent,
+	"POST /api/v1/student/vote/submit":        base.RoleStudent,
+}
+
+type permissionTable struct {
+	mutex sync.RWMutex
+	table map[string]int
+}
+
+// ParseRoles convert role names to role mask
+func ParseRoles(names []string) (int, error) {
+	role := 0
+	for _, v := range names {
+		r, ok := roleNames[v]
+		if !ok {
+			return 0, errors.Errorf("unknown role %s", v)
+		}
+		role |= r
+	}
+	return role, nil
+}
+
+// Init load the default table, then entries in file override them.
+// file example: {"POST /api/v1/auth/reset": ["admin"]}
+func (pt *permissionTable) Init(file string) error {
+	table := make(map[string]int, len(defaultPermission))
+	for k, v := range defaultPermission {
+		table[k] = v
+	}
+
+	if file != "" {
+		buff, err := ioutil.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if err == nil {
+			custom := make(map[string][]string)
+			err = json.Unmarshal(buff, &custom)
+			if err != nil {
+				return errors.Wrap(err, file)
+			}
+
+			for k, v := range custom {
+				role, err := ParseRoles(v)
+				if err != nil {
+					return errors.Wrap(err, k)
+				}
+				table[k] = role
+			}
+			logs.Info("[permissionTable::Init] permission loaded", "file", file, "count", len(custom))
+		}
+	}
+
+	pt.mutex.Lock()
+	pt.table = table
+	pt.mutex.Unlock()
+	return nil
+}
+
+// Allowed check to see if one of the roles may run the action
+func (pt *permissionTable) Allowed(role int, method, pattern string) bool {
+	pt.mutex.RLock()
+	defer pt.mutex.RUnlock()
+
+	allowed, ok := pt.table[strings.ToUpper(method)+" "+pattern]
+	if !ok {
+		logs.Warn("[permissionTable::Allowed] action not configured", "method", method, "pattern", pattern)
+		return false
+	}
+	return role&allowed != 0
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arong/dean/base"
+)
+
+func TestParseRoles(t *testing.T) {
+	role, err := ParseRoles([]string{"admin", "dean"})
+	if err != nil || role != base.RoleAdmin|base.RoleDeanOffice {
+		t.Fatal("parse roles failed", role, err)
+	}
+
+	_, err = ParseRoles([]string{"admin", "janitor"})
+	if err == nil {
+		t.Fatal("unknown role accepted")
+	}
+}
+
+func TestPermissionTable_Init(t *testing.T) {
+	dir, err := ioutil.TempDir("", "permission")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pt := permissionTable{}
+
+	// missing file falls back to default
+	err = pt.Init(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatal("init failed", err)
+	}
+	if !pt.Allowed(base.RoleDeanOffice, "post", "/api/v1/auth/reset") ||
+		pt.Allowed(base.RoleStudent, "POST", "/api/v1/auth/reset") ||
+		pt.Allowed(base.RoleStudent, "POST", "/api/v1/dean/class/add") ||
+		!pt.Allowed(base.RoleStudent, "POST", "/api/v1/student/vote/submit") {
+		t.Fatal("default permission mismatch")
+	}
+
+	// action not configured
+	if pt.Allowed(base.RoleAdmin, "GET", "/api/v1/unknown") {
+		t.Fatal("unknown action allowed")
+	}
+
+	file := filepath.Join(dir, "permission.json")
+	err = ioutil.WriteFile(file, []byte(`{"POST /api/v1/auth/reset": ["admin"], "GET /api/v1/extra": ["student"]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pt.Init(file)
+	if err != nil {
+		t.Fatal("init failed", err)
+	}
+	if pt.Allowed(base.RoleDeanOffice, "POST", "/api/v1/auth/reset") ||
+		!pt.Allowed(base.RoleAdmin, "POST", "/api/v1/auth/reset") ||
+		!pt.Allowed(base.RoleStudent, "GET", "/api/v1/extra") ||
+		!pt.Allowed(base.RoleDeanOffice, "POST", "/api/v1/dean/class/add") {
+		t.Fatal("override not applied")
+	}
+
+	err = ioutil.WriteFile(file, []byte(`{"POST /api/v1/auth/reset": ["janitor"]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt.Init(file) == nil {
+		t.Fatal("invalid role accepted")
+	}
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/arong/dean/base"
@@ -9,34 +11,72 @@ import (
 
 var QuestionnaireManager questionnaireManager
 
+//go:generate mockgen -destination=./mock_questionnaire.go -source=questionnaire.go QuestionnaireStore
+type QuestionnaireStore interface {
+	AddQuestionnaire(*QuestionnaireInfo) error
+	UpdateQuestionnaire(*QuestionnaireInfo) error
+	UpdateQuestionnaireStatus(id, from, to int) error
+	DeleteQuestionnaire(int) error
+	AddQuestion(int, *QuestionInfo) (int, error)
+	UpdateQuestion(*QuestionInfo) (int, error)
+	DeleteQuestion(int) error
+	InsertSubmission(QuestionnaireSubmit, sourceMeta) error
+	ReplaceSubmission(QuestionnaireSubmit, sourceMeta) error
+}
+
 func init() {
 	QuestionnaireManager.titleMap = make(map[string]*QuestionnaireInfo)
 	QuestionnaireManager.questions = make(map[int]*QuestionInfo)
-	QuestionnaireManager.score = make(map[int64]TeacherScore)
+	QuestionnaireManager.score = make(map[scoreKey]*TeacherScore)
+	QuestionnaireManager.submitted = make(map[submitKey]*submission)
 }
 
 type questionnaireManager struct {
 	questionnaires map[int]*QuestionnaireInfo
 	titleMap       map[string]*QuestionnaireInfo
-	questions      map[int]*QuestionInfo  // all question
-	score          map[int64]TeacherScore // teacher score
+	questions      map[int]*QuestionInfo      // all question
+	score          map[scoreKey]*TeacherScore // teacher score
+	submitted      map[submitKey]*submission  // answers already counted
+	mutex          sync.Mutex                 // protect all the maps above and status of questionnaire
+	store          QuestionnaireStore
+	scheduler      sync.Once // start scheduler only once, Init could be called again
 	//page map[int]
 }
 
+// SetStore set storage of questionnaire
+func (q *questionnaireManager) SetStore(s QuestionnaireStore) {
+	q.store = s
+}
+
 func (q *questionnaireManager) Init(idMap map[int]*QuestionnaireInfo) {
+	q.mutex.Lock()
 	q.questionnaires = idMap
 	for _, v := range idMap {
 		q.titleMap[v.Title] = v
 	}
+	q.mutex.Unlock()
+	q.scheduler.Do(func() {
+		go q.schedule()
+	})
 }
 
+// Add create questionnaire as draft, it has no question yet and shall be
+// published by Publish
 func (q *questionnaireManager) Add(info *QuestionnaireInfo) (int, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
 	if _, ok := q.titleMap[info.Title]; ok {
 		logs.Debug("[questionnaireManager::Add] name duplicated")
 		return 0, errExist
 	}
 
-	err := Ma.AddQuestionnaire(info)
+	if info.Status != QStatusDraft {
+		logs.Debug("[questionnaireManager::Add] saved as draft", "status", info.Status)
+		info.Status = QStatusDraft
+	}
+
+	err := q.store.AddQuestionnaire(info)
 	if err != nil {
 		logs.Error("[questionnaireManager::Add] failed", "err", err)
 		return 0, err
@@ -47,8 +87,12 @@ func (q *questionnaireManager) Add(info *QuestionnaireInfo) (int, error) {
 	return info.QuestionnaireID, nil
 }
 
-// many thing to do in update
+// Update change title, time and amend option of a draft, changes are
+// validated on a copy and applied after saved
 func (q *questionnaireManager) Update(info *QuestionnaireInfo) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
 	curr, ok := q.questionnaires[info.QuestionnaireID]
 	if !ok {
 		return errNotExist
@@ -63,52 +107,176 @@ func (q *questionnaireManager) Update(info *QuestionnaireInfo) error {
 		return nil
 	}
 
-	backup := *curr
-	if curr.Title != info.Title {
-		curr.Title = info.Title
+	if prev, ok := q.titleMap[info.Title]; ok && prev != curr {
+		logs.Debug("[questionnaireManager::Update] name duplicated")
+		return errExist
 	}
 
+	tmp := *curr
+	tmp.Title = info.Title
+	tmp.AllowAmend = info.AllowAmend
+
+	var err error
 	if curr.StartTime != info.StartTime {
-		tmp, err := time.Parse(base.DateTimeFormat, info.StartTime)
+		tmp.startTime, err = base.ParseDateTime(info.StartTime)
 		if err != nil {
-
+			return err
 		}
-		curr.startTime = tmp
-		curr.StartTime = info.StartTime
+		tmp.StartTime = info.StartTime
 	}
 
 	if curr.StopTime != info.StopTime {
-		tmp, err := time.Parse(base.DateTimeFormat, info.StopTime)
+		tmp.stopTime, err = base.ParseDateTime(info.StopTime)
 		if err != nil {
 			return err
 		}
-		curr.stopTime = tmp
-		curr.StopTime = info.StopTime
+		tmp.StopTime = info.StopTime
 	}
 
-	err := Ma.UpdateQuestionnaire(curr)
+	err = q.store.UpdateQuestionnaire(&tmp)
 	if err != nil {
-		curr = &backup
 		logs.Info("[questionnaireManager::Update] UpdateQuestionnaire failed", "err", err)
 		return err
 	}
+
+	delete(q.titleMap, curr.Title)
+	*curr = tmp
+	q.titleMap[curr.Title] = curr
+	return nil
+}
+
+// transit change status of questionnaire, caller shall hold the lock
+func (qm *questionnaireManager) transit(q *QuestionnaireInfo, status int) error {
+	allowed := false
+	for _, v := range qStatusTransition[q.Status] {
+		if v == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		logs.Info("[questionnaireManager::transit] not allowed", "id", q.QuestionnaireID, "from", q.Status, "to", status)
+		return errStatus
+	}
+
+	err := qm.store.UpdateQuestionnaireStatus(q.QuestionnaireID, q.Status, status)
+	if err != nil {
+		logs.Warn("[questionnaireManager::transit] UpdateQuestionnaireStatus failed", "err", err)
+		return err
+	}
+
+	logs.Info("[questionnaireManager::transit] status changed", "id", q.QuestionnaireID, "from", q.Status, "to", status)
+	q.Status = status
 	return nil
 }
 
+// Publish make questionnaire visible to student, it will be scheduled if start time not reached
+func (qm *questionnaireManager) Publish(id int) error {
+	qm.mutex.Lock()
+	defer qm.mutex.Unlock()
+
+	q, ok := qm.questionnaires[id]
+	if !ok {
+		return errNotExist
+	}
+
+	if len(q.Questions) == 0 {
+		logs.Debug("[questionnaireManager::Publish] no question")
+		return errNoQuestion
+	}
+
+	now := time.Now()
+	if !q.stopTime.IsZero() && !now.Before(q.stopTime) {
+		logs.Debug("[questionnaireManager::Publish] stop time reached")
+		return errStatus
+	}
+
+	status := QStatusPublished
+	if q.startTime.After(now) {
+		status = QStatusScheduled
+	}
+	return qm.transit(q, status)
+}
+
+// Withdraw hide questionnaire from student
+func (qm *questionnaireManager) Withdraw(id int) error {
+	qm.mutex.Lock()
+	defer qm.mutex.Unlock()
+
+	q, ok := qm.questionnaires[id]
+	if !ok {
+		return errNotExist
+	}
+	return qm.transit(q, QStatusDrawBack)
+}
+
+// Expire close questionnaire before stop time
+func (qm *questionnaireManager) Expire(id int) error {
+	qm.mutex.Lock()
+	defer qm.mutex.Unlock()
+
+	q, ok := qm.questionnaires[id]
+	if !ok {
+		return errNotExist
+	}
+	return qm.transit(q, QStatusExpired)
+}
+
+// schedule publish and expire questionnaire in time
+func (qm *questionnaireManager) schedule() {
+	qm.doSchedule(time.Now())
+
+	t := time.NewTicker(time.Minute)
+	for now := range t.C {
+		qm.doSchedule(now)
+	}
+}
+
+func (qm *questionnaireManager) doSchedule(now time.Time) {
+	qm.mutex.Lock()
+	defer qm.mutex.Unlock()
+
+	for _, q := range qm.questionnaires {
+		if q.Status != QStatusScheduled && q.Status != QStatusPublished {
+			continue
+		}
+
+		status := q.Status
+		if !q.stopTime.IsZero() && !now.Before(q.stopTime) {
+			status = QStatusExpired
+		} else if q.Status == QStatusScheduled && !now.Before(q.startTime) {
+			status = QStatusPublished
+		}
+
+		if status == q.Status {
+			continue
+		}
+
+		err := qm.transit(q, status)
+		if err != nil {
+			logs.Warn("[questionnaireManager::doSchedule] transit failed", "id", q.QuestionnaireID, "err", err)
+		}
+	}
+}
+
 type GenRequest struct {
 	StudentID       int64 `json:"-"`
 	QuestionnaireID int   `json:"questionnaire_id"`
 }
 
 func (qm *questionnaireManager) Generate(request GenRequest) (SurveyPages, error) {
+	qm.mutex.Lock()
+	defer qm.mutex.Unlock()
+
 	q, ok := qm.questionnaires[request.QuestionnaireID]
 	if !ok {
 		return nil, errNotExist
 	}
 
-	if q.Status != QStatusPublished {
-		logs.Debug("[questionnaireManager::Generate] status not allowed")
-		return nil, errNotExist
+	err := q.checkOpen(time.Now())
+	if err != nil {
+		logs.Debug("[questionnaireManager::Generate] not open", "status", q.Status, "err", err)
+		return nil, err
 	}
 
 	if len(q.Questions) == 0 {
@@ -133,17 +301,8 @@ func (qm *questionnaireManager) Generate(request GenRequest) (SurveyPages, error
 	for _, v := range class.TeacherList {
 		page := SurveyPage{TeacherID: v.TeacherID, TeacherName: v.Teacher}
 		for _, val := range q.Questions {
-			if len(val.Scope) > 0 {
-				found := false
-				for _, i := range val.Scope {
-					if i == v.SubjectID {
-						found = true
-						break
-					}
-				}
-				if !found {
-					continue
-				}
+			if !val.inScope(v.SubjectID) {
+				continue
 			}
 
 			page.Questions = append(page.Questions, val)
@@ -155,6 +314,9 @@ func (qm *questionnaireManager) Generate(request GenRequest) (SurveyPages, error
 }
 
 func (q *questionnaireManager) Delete(id int) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
 	curr, ok := q.questionnaires[id]
 	if !ok {
 		return errNotExist
@@ -164,7 +326,7 @@ func (q *questionnaireManager) Delete(id int) error {
 		return errPermission
 	}
 
-	err := Ma.DeleteQuestionnaire(id)
+	err := q.store.DeleteQuestionnaire(id)
 	if err != nil {
 		return err
 	}
@@ -175,6 +337,9 @@ func (q *questionnaireManager) Delete(id int) error {
 }
 
 func (qm *questionnaireManager) Filter() (QuestionnaireList, error) {
+	qm.mutex.Lock()
+	defer qm.mutex.Unlock()
+
 	ret := QuestionnaireList{}
 	for _, v := range qm.questionnaires {
 		tmp := QuestionnaireInfo{
@@ -184,6 +349,7 @@ func (qm *questionnaireManager) Filter() (QuestionnaireList, error) {
 			StopTime:        v.StopTime,
 			Status:          v.Status,
 			Editor:          v.Editor,
+			AllowAmend:      v.AllowAmend,
 		}
 		ret = append(ret, tmp)
 	}
@@ -192,6 +358,9 @@ func (qm *questionnaireManager) Filter() (QuestionnaireList, error) {
 
 // AddQuestion add question to questionnaire
 func (qm *questionnaireManager) AddQuestion(info *QuestionInfo) (int, error) {
+	qm.mutex.Lock()
+	defer qm.mutex.Unlock()
+
 	q, ok := qm.questionnaires[info.QuestionnaireID]
 	if !ok {
 		return 0, errNotExist
@@ -209,7 +378,7 @@ func (qm *questionnaireManager) AddQuestion(info *QuestionInfo) (int, error) {
 
 	// insert to database
 	var err error
-	info.QuestionID, err = Ma.AddQuestion(info.QuestionnaireID, info)
+	info.QuestionID, err = qm.store.AddQuestion(info.QuestionnaireID, info)
 	if err != nil {
 		logs.Warn("[AddQuestion] AddQuestion failed", "err", err)
 		return 0, err
@@ -222,6 +391,9 @@ func (qm *questionnaireManager) AddQuestion(info *QuestionInfo) (int, error) {
 
 // UpdateQuestion add question to questionnaire
 func (qm *questionnaireManager) UpdateQuestion(info *QuestionInfo) error {
+	qm.mutex.Lock()
+	defer qm.mutex.Unlock()
+
 	curr, ok := qm.questions[info.QuestionID]
 	if !ok {
 		return errNotExist
@@ -256,7 +428,7 @@ func (qm *questionnaireManager) UpdateQuestion(info *QuestionInfo) error {
 
 	// insert to database
 	var err error
-	info.QuestionID, err = Ma.UpdateQuestion(curr)
+	info.QuestionID, err = qm.store.UpdateQuestion(curr)
 	if err != nil {
 		logs.Warn("[AddQuestion] AddQuestion failed", "err", err)
 		curr = &backup
@@ -267,6 +439,9 @@ func (qm *questionnaireManager) UpdateQuestion(info *QuestionInfo) error {
 }
 
 func (qm *questionnaireManager) DeleteQuestion(id int) error {
+	qm.mutex.Lock()
+	defer qm.mutex.Unlock()
+
 	curr, ok := qm.questions[id]
 	if !ok {
 		return errNotExist
@@ -281,7 +456,7 @@ func (qm *questionnaireManager) DeleteQuestion(id int) error {
 		return errPermission
 	}
 
-	err := Ma.DeleteQuestion(id)
+	err := qm.store.DeleteQuestion(id)
 	if err != nil {
 		logs.Info("[questionnaireManager::DeleteQuestion] DeleteQuestion failed", "err", err)
 		return err
@@ -303,6 +478,9 @@ func (qm *questionnaireManager) DeleteQuestion(id int) error {
 }
 
 func (qm *questionnaireManager) GetQuestionInfo(id int) (*QuestionInfo, error) {
+	qm.mutex.Lock()
+	defer qm.mutex.Unlock()
+
 	curr, ok := qm.questions[id]
 	if !ok {
 		return nil, errNotExist
@@ -311,6 +489,9 @@ func (qm *questionnaireManager) GetQuestionInfo(id int) (*QuestionInfo, error) {
 }
 
 func (qm *questionnaireManager) GetQuestions(questionnaireID int) (QuestionList, error) {
+	qm.mutex.Lock()
+	defer qm.mutex.Unlock()
+
 	curr, ok := qm.questionnaires[questionnaireID]
 	if !ok {
 		return nil, errNotExist
@@ -326,6 +507,14 @@ func (qm *questionnaireManager) GetQuestions(questionnaireID int) (QuestionList,
 // submit questionnaire
 //Submit submit questionnaire of a student
 func (qm *questionnaireManager) Submit(req QuestionnaireSubmit) error {
+	err := req.Check()
+	if err != nil {
+		return err
+	}
+
+	qm.mutex.Lock()
+	defer qm.mutex.Unlock()
+
 	// get question info
 	curr, ok := qm.questionnaires[req.QuestionnaireID]
 	if !ok {
@@ -333,8 +522,10 @@ func (qm *questionnaireManager) Submit(req QuestionnaireSubmit) error {
 		return errNotExist
 	}
 
-	if curr.Status != QStatusPublished {
-		return errPermission
+	err = curr.checkOpen(time.Now())
+	if err != nil {
+		logs.Debug("[questionnaireManager::Submit] not open", "status", curr.Status, "err", err)
+		return err
 	}
 
 	studentInfo, err := Um.GetUser(req.StudentID)
@@ -354,71 +545,401 @@ func (qm *questionnaireManager) Submit(req QuestionnaireSubmit) error {
 	}
 
 	for _, v := range req.TeacherAnswers {
-		if _, ok := teacher[v.TeacherID]; !ok {
+		sid, ok := teacher[v.TeacherID]
+		if !ok {
 			return errPermission
 		}
 
-		ans := make(map[int]*AnswerInfo)
-		for _, val := range v.Answers {
-			ans[val.QuestionID] = val
+		err = curr.checkAnswers(v.Answers, sid)
+		if err != nil {
+			logs.Debug("[questionnaireManager::Submit] invalid answer", "teacherID", v.TeacherID, "err", err)
+			return err
 		}
+	}
+
+	source := sourceMeta{Grade: classInfo.Grade, Index: classInfo.Index}
+	key := submitKey{QuestionnaireID: req.QuestionnaireID, StudentID: req.StudentID}
 
-		for _, question := range curr.Questions {
-			tmp, ok := ans[question.QuestionID]
+	prev, ok := qm.submitted[key]
+	if !ok {
+		err = qm.store.InsertSubmission(req, source)
+		if err != nil {
+			logs.Warn("[questionnaireManager::Submit] InsertSubmission failed", "err", err)
+			return err
+		}
+	} else {
+		if !curr.AllowAmend {
+			logs.Info("[questionnaireManager::Submit] duplicated submit", "studentID", req.StudentID)
+			return errSubmitted
+		}
+
+		err = qm.store.ReplaceSubmission(req, source)
+		if err != nil {
+			logs.Warn("[questionnaireManager::Submit] ReplaceSubmission failed", "err", err)
+			return err
+		}
+
+		// withdraw previous contribution
+		for _, v := range prev.answers {
+			qm.removeScore(curr, v, prev.source)
+		}
+	}
+
+	for _, v := range req.TeacherAnswers {
+		qm.addScore(curr, v, source)
+	}
+	qm.submitted[key] = &submission{source: source, answers: req.TeacherAnswers}
+
+	return nil
+}
+
+// restore count answers loaded from storage
+func (qm *questionnaireManager) restore(q *QuestionnaireInfo, studentID int64, answer TeacherAnswer, source sourceMeta) {
+	key := submitKey{QuestionnaireID: q.QuestionnaireID, StudentID: studentID}
+	s, ok := qm.submitted[key]
+	if !ok {
+		s = &submission{source: source}
+		qm.submitted[key] = s
+	}
+	s.answers = append(s.answers, answer)
+	qm.addScore(q, answer, source)
+}
+
+// checkAnswers validate answers against questions which apply to subject
+func (q *QuestionnaireInfo) checkAnswers(answers AnswerList, subjectID int) error {
+	err := answers.Check()
+	if err != nil {
+		return err
+	}
+
+	ans := make(map[int]*AnswerInfo)
+	for _, val := range answers {
+		ans[val.QuestionID] = val
+	}
+
+	for _, question := range q.Questions {
+		if !question.inScope(subjectID) {
+			if _, ok := ans[question.QuestionID]; ok {
+				return errPermission
+			}
+			continue
+		}
+
+		tmp, ok := ans[question.QuestionID]
+		if !ok {
 			// check required question
-			if question.Required && !ok {
+			if question.Required {
 				return errNotExist
 			}
+			continue
+		}
+		delete(ans, question.QuestionID)
 
-			if len(question.Scope) > 0 {
-				found := false
-				sid := teacher[v.TeacherID]
-				for _, v := range question.Scope {
-					if v == sid {
-						found = true
-						break
-					}
-				}
-				if !found {
-					return errPermission
+		switch question.Type {
+		case QuestionTypeSingleSelection, QuestionTypeMultiSelection:
+			choices, ok := answerChoices(tmp.Answer)
+			if !ok {
+				return errInvalidInput
+			}
+			if question.Type == QuestionTypeSingleSelection && len(choices) != 1 {
+				return errInvalidInput
+			}
+			for _, choice := range choices {
+				if !question.hasOption(choice) {
+					return errInvalidInput
 				}
 			}
+		case QuestionTypeText:
+			if _, ok := tmp.Answer.(string); !ok {
+				return errInvalidInput
+			}
+		default:
+			logs.Error("[QuestionnaireInfo::checkAnswers] internal bug found")
+			return errInvalidInput
+		}
+	}
 
-			switch question.Type {
-			case QuestionTypeSingleSelection:
-				tScore := qm.score[v.TeacherID]
-				if w, ok := tmp.Answer.(float64); ok {
-					choice := int(w)
-					tScore.Count++
-					tScore.Meta[choice] = append(tScore.Meta[choice], sourceMeta{Grade: classInfo.Grade, Index: classInfo.Index})
-				} else {
-					return errInvalidInput
+	// answer to question not in this questionnaire
+	if len(ans) > 0 {
+		return errNotExist
+	}
+	return nil
+}
+
+// addScore merge answers into teacher score, answers shall be checked
+func (qm *questionnaireManager) addScore(q *QuestionnaireInfo, answer TeacherAnswer, source sourceMeta) {
+	key := scoreKey{QuestionnaireID: q.QuestionnaireID, TeacherID: answer.TeacherID}
+	tScore, ok := qm.score[key]
+	if !ok {
+		tScore = &TeacherScore{Meta: make(map[int]*questionScore)}
+		qm.score[key] = tScore
+	}
+	tScore.Total++
+
+	ans := make(map[int]*AnswerInfo)
+	for _, val := range answer.Answers {
+		ans[val.QuestionID] = val
+	}
+
+	for _, question := range q.Questions {
+		tmp, ok := ans[question.QuestionID]
+		if !ok {
+			continue
+		}
+
+		switch question.Type {
+		case QuestionTypeSingleSelection, QuestionTypeMultiSelection:
+			qs, ok := tScore.Meta[question.QuestionID]
+			if !ok {
+				qs = &questionScore{Options: make(map[int]sourceList)}
+				tScore.Meta[question.QuestionID] = qs
+			}
+			qs.Count++
+
+			choices, _ := answerChoices(tmp.Answer)
+			for _, choice := range choices {
+				tScore.Count++
+				qs.Options[choice] = append(qs.Options[choice], source)
+			}
+		case QuestionTypeText:
+			if w, ok := tmp.Answer.(string); ok && w != "" {
+				tScore.Remark = append(tScore.Remark, w)
+			}
+		}
+	}
+}
+
+// removeScore withdraw answers which merged by addScore
+func (qm *questionnaireManager) removeScore(q *QuestionnaireInfo, answer TeacherAnswer, source sourceMeta) {
+	key := scoreKey{QuestionnaireID: q.QuestionnaireID, TeacherID: answer.TeacherID}
+	tScore, ok := qm.score[key]
+	if !ok {
+		return
+	}
+	tScore.Total--
+
+	ans := make(map[int]*AnswerInfo)
+	for _, val := range answer.Answers {
+		ans[val.QuestionID] = val
+	}
+
+	for _, question := range q.Questions {
+		tmp, ok := ans[question.QuestionID]
+		if !ok {
+			continue
+		}
+
+		switch question.Type {
+		case QuestionTypeSingleSelection, QuestionTypeMultiSelection:
+			qs, ok := tScore.Meta[question.QuestionID]
+			if !ok {
+				continue
+			}
+			qs.Count--
+
+			choices, _ := answerChoices(tmp.Answer)
+			for _, choice := range choices {
+				l := len(qs.Options[choice])
+				qs.Options[choice] = qs.Options[choice].remove(source)
+				if len(qs.Options[choice]) < l {
+					tScore.Count--
 				}
-			case QuestionTypeMultiSelection:
-				tScore := qm.score[v.TeacherID]
-				if w, ok := tmp.Answer.([]float64); ok {
-					for _, i := range w {
-						choice := int(i)
-						tScore.Count++
-						tScore.Meta[choice] = append(tScore.Meta[choice], sourceMeta{Grade: classInfo.Grade, Index: classInfo.Index})
+			}
+		case QuestionTypeText:
+			if w, ok := tmp.Answer.(string); ok && w != "" {
+				for k, v := range tScore.Remark {
+					if v == w {
+						tScore.Remark = append(tScore.Remark[:k], tScore.Remark[k+1:]...)
+						break
 					}
-				} else {
-					return errInvalidInput
 				}
-			case QuestionTypeText:
-				tScore := qm.score[v.TeacherID]
-				if w, ok := tmp.Answer.(string); ok {
-					if w != "" {
-						tScore.Remark = append(tScore.Remark, w)
-					}
-				} else {
-					return errInvalidInput
+			}
+		}
+	}
+}
+
+// ResponseRate count students of the class who submitted the questionnaire
+func (qm *questionnaireManager) ResponseRate(questionnaireID int, classID int) (*ResponseRate, error) {
+	qm.mutex.Lock()
+	defer qm.mutex.Unlock()
+
+	q, ok := qm.questionnaires[questionnaireID]
+	if !ok {
+		return nil, errNotExist
+	}
+
+	students := Um.getClassStudentList(classID)
+	ret := &ResponseRate{
+		QuestionnaireID: q.QuestionnaireID,
+		Title:           q.Title,
+		ClassID:         classID,
+		Total:           len(students),
+		Pending:         []int64{},
+	}
+
+	for _, v := range students {
+		if _, ok := qm.submitted[submitKey{QuestionnaireID: questionnaireID, StudentID: v}]; ok {
+			ret.Submitted++
+		} else {
+			ret.Pending = append(ret.Pending, v)
+		}
+	}
+
+	if ret.Total > 0 {
+		ret.Rate = float64(ret.Submitted) / float64(ret.Total)
+	}
+	return ret, nil
+}
+
+// Report summarize score of teacher in the questionnaire
+func (qm *questionnaireManager) Report(questionnaireID int, teacherID int64) (*TeacherReport, error) {
+	qm.mutex.Lock()
+	defer qm.mutex.Unlock()
+
+	q, ok := qm.questionnaires[questionnaireID]
+	if !ok {
+		return nil, errNotExist
+	}
+
+	ret := &TeacherReport{
+		QuestionnaireID: q.QuestionnaireID,
+		Title:           q.Title,
+		TeacherID:       teacherID,
+		Questions:       []QuestionReport{},
+		Remarks:         []string{},
+		Sources:         []SourceReport{},
+	}
+	if t, err := Tm.GetTeacherInfo(teacherID); err == nil {
+		ret.TeacherName = t.Name
+	}
+
+	tScore, ok := qm.score[scoreKey{QuestionnaireID: questionnaireID, TeacherID: teacherID}]
+	if !ok {
+		return ret, nil
+	}
+	ret.Total = tScore.Total
+	ret.Remarks = append(ret.Remarks, tScore.Remark...)
+
+	questions := QuestionList{}
+	questions = append(questions, q.Questions...)
+	sort.Sort(questions)
+
+	var sum float64
+	var weighted int
+	for _, question := range questions {
+		if question.Type == QuestionTypeText {
+			continue
+		}
+
+		r := QuestionReport{
+			QuestionID: question.QuestionID,
+			Index:      question.Index,
+			Type:       question.Type,
+			Question:   question.Question,
+			Options:    []OptionReport{},
+			Sources:    []SourceReport{},
+		}
+
+		qs, ok := tScore.Meta[question.QuestionID]
+		if !ok {
+			ret.Questions = append(ret.Questions, r)
+			continue
+		}
+		r.Count = qs.Count
+
+		options := OptionList{}
+		options = append(options, question.Options...)
+		sort.Sort(options)
+
+		bySource := make(map[sourceMeta]*SourceReport)
+		var total int
+		for _, o := range options {
+			list := qs.Options[o.Index]
+			item := OptionReport{Index: o.Index, Option: o.Option, Count: len(list)}
+			if qs.Count > 0 {
+				item.Percent = float64(len(list)) * 100 / float64(qs.Count)
+			}
+			r.Options = append(r.Options, item)
+
+			for _, s := range list {
+				v, ok := bySource[s]
+				if !ok {
+					v = &SourceReport{Grade: s.Grade, Index: s.Index}
+					bySource[s] = v
 				}
-			default:
-				logs.Error("[questionnaireManager::Submit] internal bug found")
+				v.Count++
+				v.Average += float64(o.Index)
+				total += o.Index
 			}
 		}
+
+		// weighted by option index, only meaningful for single selection
+		if question.Type == QuestionTypeSingleSelection && qs.Count > 0 {
+			r.Average = float64(total) / float64(qs.Count)
+			sum += r.Average
+			weighted++
+		}
+
+		r.Sources = sortSource(bySource, question.Type == QuestionTypeSingleSelection)
+		ret.Questions = append(ret.Questions, r)
 	}
 
-	return nil
+	if weighted > 0 {
+		ret.Average = sum / float64(weighted)
+	}
+
+	// students who answered about the teacher, counted once per class
+	classes := make(map[sourceMeta]int)
+	for k, v := range qm.submitted {
+		if k.QuestionnaireID != questionnaireID {
+			continue
+		}
+		for _, a := range v.answers {
+			if a.TeacherID == teacherID {
+				classes[v.source]++
+				break
+			}
+		}
+	}
+	for k, v := range classes {
+		ret.Sources = append(ret.Sources, SourceReport{Grade: k.Grade, Index: k.Index, Count: v})
+	}
+	sort.Sort(SourceReportList(ret.Sources))
+	return ret, nil
+}
+
+// sortSource flatten source map, and turn sum of option into average
+func sortSource(m map[sourceMeta]*SourceReport, average bool) []SourceReport {
+	ret := SourceReportList{}
+	for _, v := range m {
+		tmp := *v
+		if average && tmp.Count > 0 {
+			tmp.Average = tmp.Average / float64(tmp.Count)
+		} else {
+			tmp.Average = 0
+		}
+		ret = append(ret, tmp)
+	}
+	sort.Sort(ret)
+	return ret
+}
+
+// answerChoices convert decoded json answer to option index list
+func answerChoices(answer interface{}) ([]int, bool) {
+	switch w := answer.(type) {
+	case float64:
+		return []int{int(w)}, true
+	case []interface{}:
+		ret := []int{}
+		for _, i := range w {
+			f, ok := i.(float64)
+			if !ok {
+				return nil, false
+			}
+			ret = append(ret, int(f))
+		}
+		return ret, true
+	default:
+		return nil, false
+	}
 }
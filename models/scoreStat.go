@@ -0,0 +1,292 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/arong/dean/base"
+	"github.com/astaxie/beego/logs"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPassPercent      = 60
+	defaultExcellentPercent = 85
+	defaultFullScore        = 100
+	defaultBucketWidth      = 10
+)
+
+// statPercentiles percentiles reported in statistics
+var statPercentiles = []int{10, 25, 50, 75, 90}
+
+// StatFilter scope and exam of statistics, one of class, grade and teacher is
+// required. a teacher covers the subjects the teacher teaches in each class
+type StatFilter struct {
+	ClassID   int   `json:"class_id"`
+	Grade     int   `json:"grade"`
+	TeacherID int64 `json:"teacher_id"`
+	SubjectID int   `json:"subject_id"` // all subjects if not set
+	TermID    int   `json:"term_id"`    // current exam if not set
+	Exam      int   `json:"exam"`
+	Pass      int   `json:"pass"`      // pass line in percent of full score, default in config if not set
+	Excellent int   `json:"excellent"` // excellent line in percent of full score, default in config if not set
+}
+
+// Percentile score under which the percent of scores fall
+type Percentile struct {
+	Percent int     `json:"percent"`
+	Score   float64 `json:"score"`
+}
+
+// Bucket count of scores in [From, To), the last bucket includes To
+type Bucket struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Count int `json:"count"`
+}
+
+// SubjectStat statistics of a subject in an exam
+type SubjectStat struct {
+	SubjectID     int          `json:"subject_id"`
+	Subject       string       `json:"subject"`
+	FullScore     int          `json:"full_score"`
+	Count         int          `json:"count"`
+	Mean          float64      `json:"mean"`
+	Median        float64      `json:"median"`
+	StdDev        float64      `json:"std_dev"`
+	Max           int          `json:"max"`
+	Min           int          `json:"min"`
+	Percentiles   []Percentile `json:"percentiles"`
+	PassRate      float64      `json:"pass_rate"`
+	ExcellentRate float64      `json:"excellent_rate"`
+	Histogram     []Bucket     `json:"histogram"`
+}
+
+type SubjectStatList []SubjectStat
+
+func (sl SubjectStatList) Len() int {
+	return len(sl)
+}
+
+func (sl SubjectStatList) Swap(i, j int) {
+	sl[i], sl[j] = sl[j], sl[i]
+}
+
+func (sl SubjectStatList) Less(i, j int) bool {
+	return sl[i].SubjectID < sl[j].SubjectID
+}
+
+// SetThreshold set default pass and excellent line in percent of full score,
+// and width of histogram bucket, zero keeps the default
+func (ssm *StudentScoreManager) SetThreshold(pass, excellent, bucket int) error {
+	if pass < 0 || excellent < 0 || bucket < 0 || pass > 100 || excellent > 100 {
+		return errors.New("invalid threshold")
+	}
+	if pass == 0 {
+		pass = defaultPassPercent
+	}
+	if excellent == 0 {
+		excellent = defaultExcellentPercent
+	}
+	if excellent < pass {
+		return errors.New("excellent under pass")
+	}
+
+	ssm.mutex.Lock()
+	defer ssm.mutex.Unlock()
+	ssm.pass, ssm.excellent, ssm.bucket = pass, excellent, bucket
+	return nil
+}
+
+// SetFullScore set full score of subjects by key, e.g. "math:150", subjects
+// not listed are of 100
+func (ssm *StudentScoreManager) SetFullScore(list []string) error {
+	fullScore := make(map[string]int)
+	for _, v := range list {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		pair := strings.Split(v, ":")
+		if len(pair) != 2 {
+			return errors.New("invalid full score " + v)
+		}
+		score, err := strconv.Atoi(strings.TrimSpace(pair[1]))
+		if err != nil || score <= 0 || score > base.MaxScore {
+			return errors.New("invalid full score " + v)
+		}
+		fullScore[strings.TrimSpace(pair[0])] = score
+	}
+
+	ssm.mutex.Lock()
+	defer ssm.mutex.Unlock()
+	ssm.fullScore = fullScore
+	return nil
+}
+
+// thresholds in effect, caller holds mutex
+func (ssm *StudentScoreManager) thresholds() (int, int, int) {
+	pass, excellent, bucket := ssm.pass, ssm.excellent, ssm.bucket
+	if pass == 0 {
+		pass = defaultPassPercent
+	}
+	if excellent == 0 {
+		excellent = defaultExcellentPercent
+	}
+	if bucket == 0 {
+		bucket = defaultBucketWidth
+	}
+	return pass, excellent, bucket
+}
+
+// Statistics per subject of the exam for a class, a grade or classes of a teacher
+func (ssm *StudentScoreManager) Statistics(f StatFilter) (SubjectStatList, error) {
+	termID, exam, err := ssm.exam(f.TermID == 0, f.TermID, f.Exam)
+	if err != nil {
+		return nil, err
+	}
+
+	// subjects counted for students of each class, nil means all
+	scope := make(map[int][]int)
+	switch {
+	case f.ClassID != 0:
+		if _, err = Cm.GetInfo(f.ClassID); err != nil {
+			return nil, err
+		}
+		scope[f.ClassID] = nil
+	case f.Grade != 0:
+		for _, v := range Cm.gradeClasses(f.Grade) {
+			scope[v] = nil
+		}
+	case f.TeacherID != 0:
+		scope = Cm.InstructorClasses(f.TeacherID)
+	default:
+		return nil, errInvalidParam
+	}
+
+	if f.Pass < 0 || f.Excellent < 0 || f.Pass > 100 || f.Excellent > 100 {
+		return nil, errInvalidParam
+	}
+
+	ssm.mutex.Lock()
+	pass, excellent, bucket := ssm.thresholds()
+	fullScore := ssm.fullScore
+	samples := make(map[int][]int)
+	for classID, subjects := range scope {
+		for _, id := range Um.getClassStudentList(classID) {
+			for _, v := range ssm.examScore(id, termID, exam) {
+				if f.SubjectID != 0 && v.SubjectID != f.SubjectID {
+					continue
+				}
+				if subjects != nil && !containsInt(subjects, v.SubjectID) {
+					continue
+				}
+				samples[v.SubjectID] = append(samples[v.SubjectID], v.Score)
+			}
+		}
+	}
+	ssm.mutex.Unlock()
+
+	if f.Pass != 0 {
+		pass = f.Pass
+	}
+	if f.Excellent != 0 {
+		excellent = f.Excellent
+	}
+	if excellent < pass {
+		logs.Debug("[StudentScoreManager::Statistics] excellent under pass", "pass", pass, "excellent", excellent)
+		return nil, errInvalidParam
+	}
+
+	ret := SubjectStatList{}
+	for k, v := range samples {
+		full, ok := fullScore[Sm.getSubjectKey(k)]
+		if !ok {
+			full = defaultFullScore
+		}
+		stat := newSubjectStat(v, scoreLine(full, pass), scoreLine(full, excellent), bucket)
+		stat.SubjectID = k
+		stat.Subject = Sm.getSubjectName(k)
+		stat.FullScore = full
+		ret = append(ret, stat)
+	}
+	sort.Sort(ret)
+
+	logs.Debug("[StudentScoreManager::Statistics]", "termID", termID, "exam", exam, "subjects", len(ret))
+	return ret, nil
+}
+
+// scoreLine lowest score reaching percent of the full score
+func scoreLine(full, percent int) int {
+	return (full*percent + 99) / 100
+}
+
+func containsInt(list []int, v int) bool {
+	for _, i := range list {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+// newSubjectStat compute statistics of the scores, scores must not be empty
+func newSubjectStat(scores []int, pass, excellent, bucket int) SubjectStat {
+	sorted := append([]int{}, scores...)
+	sort.Ints(sorted)
+
+	n := len(sorted)
+	ret := SubjectStat{Count: n, Min: sorted[0], Max: sorted[n-1]}
+
+	sum, passed, excelled := 0, 0, 0
+	for _, v := range sorted {
+		sum += v
+		if v >= pass {
+			passed++
+		}
+		if v >= excellent {
+			excelled++
+		}
+	}
+	ret.Mean = float64(sum) / float64(n)
+	ret.PassRate = float64(passed) / float64(n)
+	ret.ExcellentRate = float64(excelled) / float64(n)
+
+	variance := 0.0
+	for _, v := range sorted {
+		variance += (float64(v) - ret.Mean) * (float64(v) - ret.Mean)
+	}
+	ret.StdDev = math.Sqrt(variance / float64(n))
+
+	ret.Median = percentile(sorted, 50)
+	for _, p := range statPercentiles {
+		ret.Percentiles = append(ret.Percentiles, Percentile{Percent: p, Score: percentile(sorted, p)})
+	}
+
+	// fixed buckets over the full range, so that histograms are comparable
+	for from := base.MinScore; from < base.MaxScore; from += bucket {
+		to := from + bucket
+		if to > base.MaxScore {
+			to = base.MaxScore
+		}
+		ret.Histogram = append(ret.Histogram, Bucket{From: from, To: to})
+	}
+	for _, v := range sorted {
+		i := (v - base.MinScore) / bucket
+		if i >= len(ret.Histogram) {
+			i = len(ret.Histogram) - 1
+		}
+		ret.Histogram[i].Count++
+	}
+	return ret
+}
+
+// percentile linear interpolation between closest ranks of the sorted scores
+func percentile(sorted []int, p int) float64 {
+	pos := float64(p) / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return float64(sorted[lower]) + (pos-float64(lower))*float64(sorted[upper]-sorted[lower])
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"math"
+	"testing"
+
+	"github.com/arong/dean/base"
+)
+
+func TestNewSubjectStat(t *testing.T) {
+	stat := newSubjectStat([]int{90, 50, 70, 60, 80, base.MaxScore}, 60, 85, 10)
+	if stat.Count != 6 || stat.Min != 50 || stat.Max != base.MaxScore {
+		t.Fatal("count or range mismatch", stat)
+	}
+	if stat.Mean != 500.0/6 || stat.Median != 75 {
+		t.Fatal("mean or median mismatch", stat.Mean, stat.Median)
+	}
+
+	variance := 0.0
+	for _, v := range []float64{90, 50, 70, 60, 80, base.MaxScore} {
+		variance += (v - stat.Mean) * (v - stat.Mean)
+	}
+	if math.Abs(stat.StdDev-math.Sqrt(variance/6)) > 1e-9 {
+		t.Fatal("stddev mismatch", stat.StdDev)
+	}
+
+	// sorted 50 60 70 80 90 150, p10 at 0.5, p90 at 4.5
+	if len(stat.Percentiles) != len(statPercentiles) || stat.Percentiles[0].Score != 55 || stat.Percentiles[4].Score != 120 {
+		t.Fatal("percentile mismatch", stat.Percentiles)
+	}
+	if stat.PassRate != 5.0/6 || stat.ExcellentRate != 2.0/6 {
+		t.Fatal("rate mismatch", stat.PassRate, stat.ExcellentRate)
+	}
+
+	if len(stat.Histogram) != base.MaxScore/10 {
+		t.Fatal("bucket count mismatch", len(stat.Histogram))
+	}
+	for k, v := range stat.Histogram {
+		expect := 0
+		if k >= 5 && k <= 9 || k == len(stat.Histogram)-1 {
+			expect = 1
+		}
+		if v.Count != expect || v.From != k*10 {
+			t.Fatal("histogram mismatch", k, v)
+		}
+	}
+}
+
+func TestStudentScoreManager_Statistics(t *testing.T) {
+	Sm.Init(SubjectList{{ID: 1, Name: "数学", Key: "math"}, {ID: 2, Name: "语文", Key: "chinese"}})
+	defer Sm.Init(nil)
+	Cm = classManager{idMap: map[int]*Class{
+		1: {ID: 1, Filter: Filter{Grade: 1, Index: 1}, TeacherList: InstructorList{{TeacherID: 10, SubjectID: 1}}},
+		2: {ID: 2, Filter: Filter{Grade: 1, Index: 2}, TeacherList: InstructorList{{TeacherID: 10, SubjectID: 2}}},
+		3: {ID: 3, Filter: Filter{Grade: 2, Index: 1}, TeacherList: InstructorList{{TeacherID: 10, SubjectID: 1}}},
+	}}
+	defer func() { Cm = classManager{} }()
+	Um.Init(map[int64]*StudentInfo{
+		1: {StudentID: 1, ClassID: 1},
+		2: {StudentID: 2, ClassID: 1},
+		3: {StudentID: 3, ClassID: 2},
+		4: {StudentID: 4, ClassID: 3},
+	})
+	defer Um.Init(nil)
+
+	score := func(id int64, math, chinese int) StudentScore {
+		return StudentScore{StudentID: id, TermID: 20191, Exam: 1, Scores: ScorePairList{{SubjectID: 1, Score: math}, {SubjectID: 2, Score: chinese}}}
+	}
+	ssm := StudentScoreManager{}
+	ssm.Init(StudentScoreList{score(1, 90, 50), score(2, 70, 60), score(3, 80, 100), score(4, 40, 40)})
+
+	if _, err := ssm.Statistics(StatFilter{}); err != errInvalidParam {
+		t.Fatal("statistics without scope", err)
+	}
+
+	ret, err := ssm.Statistics(StatFilter{ClassID: 1})
+	if err != nil || len(ret) != 2 || ret[0].Subject != "数学" || ret[0].Mean != 80 || ret[1].PassRate != 0.5 {
+		t.Fatal("class statistics mismatch", ret, err)
+	}
+
+	ret, err = ssm.Statistics(StatFilter{Grade: 1, SubjectID: 1, TermID: 20191, Exam: 1})
+	if err != nil || len(ret) != 1 || ret[0].Count != 3 || ret[0].Median != 80 {
+		t.Fatal("grade statistics mismatch", ret, err)
+	}
+
+	// math of class 1 and 3, chinese of class 2
+	ret, err = ssm.Statistics(StatFilter{TeacherID: 10})
+	if err != nil || len(ret) != 2 || ret[0].Count != 3 || ret[0].Min != 40 || ret[1].Count != 1 || ret[1].Max != 100 {
+		t.Fatal("teacher statistics mismatch", ret, err)
+	}
+
+	if err = ssm.SetThreshold(90, 80, 0); err == nil {
+		t.Fatal("excellent under pass accepted")
+	}
+	if err = ssm.SetThreshold(80, 90, 50); err != nil {
+		t.Fatal(err)
+	}
+	ret, err = ssm.Statistics(StatFilter{ClassID: 1, SubjectID: 1})
+	if err != nil || ret[0].PassRate != 0.5 || ret[0].ExcellentRate != 0.5 || len(ret[0].Histogram) != 3 {
+		t.Fatal("threshold not applied", ret, err)
+	}
+	ret, err = ssm.Statistics(StatFilter{ClassID: 1, SubjectID: 1, Pass: 60, Excellent: 95})
+	if err != nil || ret[0].PassRate != 1 || ret[0].ExcellentRate != 0 {
+		t.Fatal("threshold in request not applied", ret, err)
+	}
+	if _, err = ssm.Statistics(StatFilter{ClassID: 1, Pass: 90, Excellent: 80}); err != errInvalidParam {
+		t.Fatal("excellent under pass in request accepted", err)
+	}
+	if _, err = ssm.Statistics(StatFilter{ClassID: 1, Pass: 120}); err != errInvalidParam {
+		t.Fatal("pass over full score accepted", err)
+	}
+
+	// lines follow full score of the subject, 60% of 150 is 90
+	if err = ssm.SetFullScore([]string{"math"}); err == nil {
+		t.Fatal("invalid full score accepted")
+	}
+	if err = ssm.SetFullScore([]string{"math:150", ""}); err != nil {
+		t.Fatal(err)
+	}
+	ret, err = ssm.Statistics(StatFilter{ClassID: 1, Pass: 60})
+	if err != nil || ret[0].FullScore != 150 || ret[0].PassRate != 0.5 || ret[0].ExcellentRate != 0 ||
+		ret[1].FullScore != defaultFullScore || ret[1].PassRate != 0.5 {
+		t.Fatal("full score not applied", ret, err)
+	}
+}
@@ -0,0 +1,308 @@
+package models
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	maxSheetSize    = 4 << 20  // bytes of uploaded file
+	maxSheetRows    = 2000     // data rows in one import
+	maxSheetColumns = 64       // columns read, the ones after are ignored
+	maxXLSXEntry    = 32 << 20 // bytes of a file in xlsx after decompressed
+)
+
+var (
+	// ErrSheetFormat file is neither csv nor xlsx, or is broken
+	ErrSheetFormat = errors.New("unsupported sheet, csv or xlsx expected")
+	// ErrSheetTooLarge file or rows exceed the limit
+	ErrSheetTooLarge = errors.New("sheet too large")
+	errDate          = errors.New("invalid date")
+)
+
+// readSheet read rows of the csv or the first worksheet of the xlsx file,
+// format is decided by the extension of name
+func readSheet(name string, r io.Reader) ([][]string, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxSheetSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSheetSize {
+		return nil, ErrSheetTooLarge
+	}
+
+	var rows [][]string
+	switch strings.ToLower(path.Ext(name)) {
+	case ".csv":
+		rows, err = readCSV(data)
+	case ".xlsx":
+		rows, err = readXLSX(data)
+	default:
+		return nil, ErrSheetFormat
+	}
+	if err != nil {
+		if err == ErrSheetTooLarge {
+			return nil, err
+		}
+		return nil, errors.Wrap(ErrSheetFormat, err.Error())
+	}
+
+	if len(rows) > maxSheetRows+1 {
+		return nil, ErrSheetTooLarge
+	}
+	return rows, nil
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	// excel saves utf-8 csv with bom
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	// blank lines are skipped by the reader, pad them to keep row number
+	ret := [][]string{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return ret, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := r.FieldPos(0)
+		if line > maxSheetRows+1 {
+			return nil, ErrSheetTooLarge
+		}
+		for len(ret)+1 < line {
+			ret = append(ret, []string{})
+		}
+		ret = append(ret, record)
+	}
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelations struct {
+	List []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (x xlsxText) String() string {
+	if len(x.R) == 0 {
+		return x.T
+	}
+	ret := ""
+	for _, v := range x.R {
+		ret += v.T
+	}
+	return ret
+}
+
+type xlsxSharedStrings struct {
+	List []xlsxText `xml:"si"`
+}
+
+type xlsxRow struct {
+	Index int `xml:"r,attr"`
+	Cells []struct {
+		Ref    string   `xml:"r,attr"`
+		Type   string   `xml:"t,attr"`
+		Value  string   `xml:"v"`
+		Inline xlsxText `xml:"is"`
+	} `xml:"c"`
+}
+
+// readXLSX read cells of the first worksheet as text, rows omitted by excel
+// are kept empty so that index of the result follows the row number
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*zip.File)
+	for _, v := range zr.File {
+		files[v.Name] = v
+	}
+
+	// size declared in the header is checked, and the reader is limited in
+	// case the header lies
+	open := func(name string) (io.ReadCloser, error) {
+		f, ok := files[name]
+		if !ok {
+			return nil, errors.New(name + " not found")
+		}
+		if f.UncompressedSize64 > maxXLSXEntry {
+			return nil, ErrSheetTooLarge
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(rc, int64(f.UncompressedSize64)), rc}, nil
+	}
+	decode := func(name string, v interface{}) error {
+		rc, err := open(name)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		return xml.NewDecoder(rc).Decode(v)
+	}
+
+	// first worksheet is found through the relations of the workbook
+	workbook := xlsxWorkbook{}
+	rels := xlsxRelations{}
+	if err = decode("xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if err = decode("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, errors.New("no worksheet")
+	}
+	sheet := ""
+	for _, v := range rels.List {
+		if v.ID == workbook.Sheets[0].ID {
+			sheet = path.Join("xl", v.Target)
+			if strings.HasPrefix(v.Target, "/") {
+				sheet = strings.TrimPrefix(v.Target, "/")
+			}
+		}
+	}
+
+	shared := xlsxSharedStrings{}
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err = decode("xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	rc, err := open(sheet)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	// rows are decoded one by one, so that it stops once over the limit
+	ret := [][]string{}
+	d := xml.NewDecoder(rc)
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return ret, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		row := xlsxRow{}
+		if err = d.DecodeElement(&row, &start); err != nil {
+			return nil, err
+		}
+		if row.Index > maxSheetRows+1 || len(ret) > maxSheetRows {
+			return nil, ErrSheetTooLarge
+		}
+		for len(ret)+1 < row.Index {
+			ret = append(ret, []string{})
+		}
+
+		line := []string{}
+		for i, c := range row.Cells {
+			col := i
+			if c.Ref != "" {
+				col = xlsxColumn(c.Ref)
+			}
+			if col < 0 || col >= maxSheetColumns {
+				continue
+			}
+			for len(line) <= col {
+				line = append(line, "")
+			}
+
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+				if err != nil || idx < 0 || idx >= len(shared.List) {
+					return nil, errors.New("invalid shared string " + c.Ref)
+				}
+				line[col] = shared.List[idx].String()
+			case "inlineStr":
+				line[col] = c.Inline.String()
+			default:
+				line[col] = c.Value
+			}
+		}
+		ret = append(ret, line)
+	}
+}
+
+// xlsxColumn zero based column of the cell reference, "B3" is 1. -1 if the
+// reference is beyond "XFD", the last column of excel
+func xlsxColumn(ref string) int {
+	col := 0
+	for _, v := range ref {
+		if v < 'A' || v > 'Z' {
+			break
+		}
+		col = col*26 + int(v-'A'+1)
+		if col > 16384 {
+			return -1
+		}
+	}
+	return col - 1
+}
+
+// sheetDate accept 2006-01-02, 2006/1/2 and the serial number excel keeps
+// for date cells, output is formatted as 2006-01-02
+func sheetDate(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+
+	if serial, err := strconv.ParseFloat(s, 64); err == nil && serial > 0 && serial < 100000 {
+		return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(serial)).Format("2006-01-02"), nil
+	}
+
+	for _, layout := range []string{"2006-01-02", "2006-1-2", "2006/1/2", "2006.1.2"} {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t.Format("2006-01-02"), nil
+		}
+	}
+	return "", errDate
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Sa sqlite agent
+var Sa sqliteAgent
+
+// sqliteAgent stores data in an embedded sqlite file.
+// statements used by mysqlAgent are portable, so they are shared.
+type sqliteAgent struct {
+	mysqlAgent
+}
+
+func (sa *sqliteAgent) Init(conf *DBConfig) {
+	var err error
+	sa.db, err = sql.Open(DriverSQLite, conf.source())
+	if err != nil {
+		panic("cannot open sqlite database")
+	}
+}
+
+// UpsertScores sqlite has its own upsert syntax
+func (sa *sqliteAgent) UpsertScores(list StudentScoreList) error {
+	return sa.upsertScores("INSERT INTO `tbStudentScore` (`iStudentID`,`iTermID`,`eExam`,`iSubjectID`,`iScore`) VALUES (?,?,?,?,?) ON CONFLICT(`iStudentID`,`iTermID`,`eExam`,`iSubjectID`) DO UPDATE SET `iScore`=excluded.`iScore`;", list)
+}
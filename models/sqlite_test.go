@@ -0,0 +1,205 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arong/dean/base"
+)
+
+func TestSqliteAgent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dean")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := &DBConfig{Driver: DriverSQLite, Path: filepath.Join(dir, "dean.db"), Migration: "../sql/migrations"}
+	m, err := NewMigrator(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	err = m.Up(m.Latest())
+	if err != nil {
+		t.Fatal("migrate failed", err)
+	}
+
+	sa := sqliteAgent{}
+	sa.Init(conf)
+	defer sa.db.Close()
+
+	subjectID, err := sa.SaveSubject(SubjectInfo{Key: "math", Name: "数学"})
+	if err != nil {
+		t.Fatal("save subject failed", err)
+	}
+
+	teacherID, err := sa.InsertTeacher(Teacher{TeacherMeta: TeacherMeta{Name: "赵一", Gender: eGenderMale, SubjectID: subjectID, Birthday: "1980-01-01"}})
+	if err != nil {
+		t.Fatal("insert teacher failed", err)
+	}
+
+	studentID, err := sa.InsertStudent(&StudentInfo{profile: profile{RealName: "钱二", Gender: eGenderFemale, Birthday: "2008-01-01", Mobile: "13800000000"}, RegisterID: "2019001"})
+	if err != nil {
+		t.Fatal("insert student failed", err)
+	}
+
+	err = sa.DeleteStudent(studentID)
+	if err != nil {
+		t.Fatal("delete student failed", err)
+	}
+
+	imported := []*StudentInfo{
+		{profile: profile{RealName: "孙三", Gender: eGenderMale, Birthday: "2008-02-01"}, RegisterID: "2019003"},
+		{profile: profile{RealName: "李四", Gender: eGenderMale, Birthday: "2008-03-01"}, RegisterID: "2019004"},
+		{profile: profile{RealName: "周五", Gender: eGenderMale}, RegisterID: "2019005"},
+	}
+	err = sa.InsertStudents(imported)
+	if err != nil || imported[0].StudentID == 0 || imported[1].StudentID <= imported[0].StudentID || imported[2].StudentID == 0 {
+		t.Fatal("insert students failed", err)
+	}
+
+	// address and birthday survive reload, empty birthday is kept as default
+	updated := *imported[1]
+	updated.Address = "北京"
+	updated.Birthday = ""
+	err = sa.UpdateStudent(&updated)
+	if err != nil {
+		t.Fatal("update student failed", err)
+	}
+
+	// upsert keeps one row per student, term, exam and subject
+	for _, v := range []int{80, 95} {
+		err = sa.UpsertScores(StudentScoreList{{StudentID: imported[0].StudentID, TermID: 20191, Exam: 1, Scores: ScorePairList{{SubjectID: subjectID, Score: v}}}})
+		if err != nil {
+			t.Fatal("upsert score failed", err)
+		}
+	}
+
+	// answers to each teacher are saved together
+	err = sa.InsertSubmission(QuestionnaireSubmit{QuestionnaireID: 1, StudentID: imported[0].StudentID,
+		TeacherAnswers: TeacherAnswerList{{TeacherID: teacherID}, {TeacherID: teacherID + 1}}}, sourceMeta{Grade: 1, Index: 1})
+	count := 0
+	if err != nil || sa.db.QueryRow("SELECT COUNT(*) FROM tbSubmission;").Scan(&count) != nil || count != 2 {
+		t.Fatal("insert submission failed", count, err)
+	}
+	dup := QuestionnaireSubmit{QuestionnaireID: 1, StudentID: imported[0].StudentID, TeacherAnswers: TeacherAnswerList{{TeacherID: teacherID}}}
+	if err = sa.InsertSubmission(dup, sourceMeta{Grade: 1, Index: 1}); err == nil {
+		t.Fatal("duplicated submission saved")
+	}
+	// amended twice, the last archive is kept
+	for i := 0; i < 2; i++ {
+		err = sa.ReplaceSubmission(dup, sourceMeta{Grade: 1, Index: 1})
+		if err != nil {
+			t.Fatal("replace submission failed", err)
+		}
+	}
+	if sa.db.QueryRow("SELECT COUNT(*) FROM tbSubmission;").Scan(&count) != nil || count != 2 {
+		t.Fatal("archive not replaced", count)
+	}
+
+	// accounts of a teacher and a student sharing the same id
+	teacherKey := LoginKey{UserType: base.AccountTypeTeacher, LoginName: "zhao"}
+	studentKey := LoginKey{UserType: base.AccountTypeStudent, LoginName: "2019001"}
+	for _, v := range []*LoginInfo{
+		{ID: teacherID, UserType: base.AccountTypeTeacher, LoginName: "zhao0", Password: "old", Status: base.AccountStatusActive, MustChange: true},
+		{ID: teacherID, UserType: base.AccountTypeStudent, LoginName: "2019001", Password: "old", Status: base.AccountStatusActive},
+	} {
+		err = sa.InsertPassword(v)
+		if err != nil {
+			t.Fatal("insert password failed", err)
+		}
+	}
+	err = sa.UpdatePassword(teacherID, base.AccountTypeTeacher, "new", false)
+	if err != nil {
+		t.Fatal("update password failed", err)
+	}
+	err = sa.UpdateAccountStatus(teacherID, base.AccountTypeTeacher, base.AccountStatusDisabled)
+	if err != nil {
+		t.Fatal("update status failed", err)
+	}
+	err = sa.UpdateLoginName(teacherID, base.AccountTypeTeacher, "zhao")
+	if err != nil {
+		t.Fatal("update login name failed", err)
+	}
+
+	err = sa.LoadAllData()
+	if err != nil {
+		t.Fatal("load data failed", err)
+	}
+
+	if l := Ac.loginMap[teacherKey]; l == nil || l.Password != "new" || l.MustChange || l.Status != base.AccountStatusDisabled {
+		t.Fatal("teacher account not updated", l)
+	}
+	if l := Ac.loginMap[studentKey]; l == nil || l.Password != "old" || l.Status != base.AccountStatusActive {
+		t.Fatal("student account changed", l)
+	}
+
+	if !Sm.IsExist(subjectID) {
+		t.Fatal("subject not loaded")
+	}
+
+	info, err := Tm.GetTeacherInfo(teacherID)
+	if err != nil || info.Name != "赵一" || info.Age == 0 {
+		t.Fatal("teacher not loaded", err)
+	}
+
+	if Um.IsExist(studentID) {
+		t.Fatal("deleted student loaded")
+	}
+	if s, err := Um.GetStudentByRegisterNumber("2019004"); err != nil || s.StudentID != imported[1].StudentID || s.Address != "北京" || s.Birthday != "" {
+		t.Fatal("imported student not loaded", s, err)
+	}
+	if s, err := Um.GetStudentByRegisterNumber("2019003"); err != nil || s.Birthday != "2008-02-01" || s.Age == 0 {
+		t.Fatal("birthday not loaded", s, err)
+	}
+	if score, err := SSM.getStudentScore(imported[0].StudentID); err != nil || len(score.Scores) != 1 || score.Scores[0].Score != 95 {
+		t.Fatal("score not loaded", score, err)
+	}
+}
+
+func TestSqliteAgent_InsertClass(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dean")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := &DBConfig{Driver: DriverSQLite, Path: filepath.Join(dir, "dean.db"), Migration: "../sql/migrations"}
+	m, err := NewMigrator(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	err = m.Up(m.Latest())
+	if err != nil {
+		t.Fatal("migrate failed", err)
+	}
+
+	sa := sqliteAgent{}
+	sa.Init(conf)
+	defer sa.db.Close()
+
+	// relation insert fails, class row should be rolled back
+	_, err = sa.db.Exec("DROP TABLE tbClassTeacherRelation;")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newClass()
+	c.ID = 0
+	err = sa.InsertClass(c)
+	if err == nil || c.ID != 0 {
+		t.Fatal("insert class success", c.ID)
+	}
+
+	count := 0
+	err = sa.db.QueryRow("SELECT COUNT(*) FROM tbClass;").Scan(&count)
+	if err != nil || count != 0 {
+		t.Fatal("class row not rolled back", count, err)
+	}
+}
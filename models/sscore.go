@@ -1,22 +1,302 @@
 package models
 
+import (
+	"sort"
+	"sync"
+
+	"github.com/arong/dean/base"
+	"github.com/astaxie/beego/logs"
+	"github.com/pkg/errors"
+)
+
 //SSM is global student score manager
 var SSM StudentScoreManager
 
+// ErrTerm term id is year*10 + term, term being 1 or 2
+var ErrTerm = errors.New("invalid term id")
+
+//go:generate mockgen -destination=./mock_sscore.go -source=sscore.go ScoreStore
+type ScoreStore interface {
+	UpsertScores(StudentScoreList) error
+}
+
+// MakeTermID term id of the school year, term is 1 or 2
+func MakeTermID(year, term int) int {
+	return year*10 + term
+}
+
+// splitTermID school year and term of the term id
+func splitTermID(termID int) (int, int, error) {
+	year, term := termID/10, termID%10
+	if year <= 0 || (term != 1 && term != 2) {
+		return 0, 0, ErrTerm
+	}
+	return year, term, nil
+}
+
 type StudentScoreManager struct {
-	currentYear int // 当前学年
-	currentTerm int // 当前学期
-	currentExam int // 当前考试
+	mutex       sync.Mutex
+	currentYear int            // 当前学年
+	currentTerm int            // 当前学期
+	currentExam int            // 当前考试
+	pass        int            // 及格线, 满分的百分比
+	excellent   int            // 优秀线, 满分的百分比
+	bucket      int            // 分数段宽度
+	fullScore   map[string]int // 科目满分, subject key -> score
 	score       map[int64]YearScoreList
+	store       ScoreStore
+}
+
+// SetStore set storage of score
+func (ssm *StudentScoreManager) SetStore(s ScoreStore) {
+	ssm.store = s
+}
+
+// Init build score of students from records loaded, the current exam is the
+// latest one until SetCurrent is called
+func (ssm *StudentScoreManager) Init(list StudentScoreList) {
+	ssm.mutex.Lock()
+	defer ssm.mutex.Unlock()
+
+	ssm.score = make(map[int64]YearScoreList)
+	ssm.currentYear, ssm.currentTerm, ssm.currentExam = 0, 0, 0
+	for _, v := range list {
+		if ssm.put(v) != nil {
+			logs.Warn("[StudentScoreManager::Init] invalid term", "studentID", v.StudentID, "termID", v.TermID)
+			continue
+		}
+		if v.TermID > ssm.currentTerm || (v.TermID == ssm.currentTerm && v.Exam > ssm.currentExam) {
+			ssm.currentTerm, ssm.currentExam = v.TermID, v.Exam
+			ssm.currentYear = v.TermID / 10
+		}
+	}
+	logs.Info("[StudentScoreManager::Init] score loaded", "records", len(list), "term", ssm.currentTerm, "exam", ssm.currentExam)
+}
+
+// SetCurrent set the exam current score refers to, zero keeps the latest one
+func (ssm *StudentScoreManager) SetCurrent(termID, exam int) error {
+	if termID == 0 && exam == 0 {
+		return nil
+	}
+
+	year, _, err := splitTermID(termID)
+	if err != nil {
+		return err
+	}
+	if exam <= 0 {
+		return errors.New("invalid exam")
+	}
+
+	ssm.mutex.Lock()
+	defer ssm.mutex.Unlock()
+	ssm.currentYear, ssm.currentTerm, ssm.currentExam = year, termID, exam
+	return nil
 }
 
-// AddRecord: add new record
-func (ssm StudentScoreManager) AddRecord(r StudentScore) error {
+// AddRecord save scores of the student, existing scores of the same subject
+// in the exam are overwritten. teacherID not zero must teach every subject in
+// the class of the student
+func (ssm *StudentScoreManager) AddRecord(r StudentScore, teacherID int64) error {
+	if _, _, err := splitTermID(r.TermID); err != nil {
+		return err
+	}
+	if len(r.Scores) == 0 {
+		return nil
+	}
+
+	if teacherID != 0 {
+		s, err := Um.GetUser(r.StudentID)
+		if err != nil {
+			return err
+		}
+		for _, v := range r.Scores {
+			if !Cm.IsInstructor(s.ClassID, v.SubjectID, teacherID) {
+				logs.Debug("[StudentScoreManager::AddRecord] not instructor", "teacherID", teacherID, "subjectID", v.SubjectID)
+				return ErrNotInstructor
+			}
+		}
+	}
+
+	err := ssm.store.UpsertScores(StudentScoreList{r})
+	if err != nil {
+		logs.Warn("[StudentScoreManager::AddRecord] UpsertScores failed", "err", err)
+		return err
+	}
+
+	ssm.mutex.Lock()
+	defer ssm.mutex.Unlock()
+	ssm.put(r)
 	return nil
 }
 
-func (ssm StudentScoreManager) getStudentScore(studentID int64) (StudentScore, error) {
-	item := StudentScore{}
+// ClassScore scores of one subject in an exam for students of a class
+type ClassScore struct {
+	ClassID   int             `json:"class_id"`
+	SubjectID int             `json:"subject_id"`
+	TermID    int             `json:"term_id"`
+	Exam      int             `json:"exam"`
+	Scores    []ClassScoreRow `json:"scores"`
+}
+
+// ClassScoreRow score of a student, identified by id or register number.
+// Row is the row number in the sheet
+type ClassScoreRow struct {
+	Row        int    `json:"row,omitempty"`
+	StudentID  int64  `json:"student_id,omitempty"`
+	RegisterID string `json:"register_id,omitempty"`
+	Score      int    `json:"score"`
+}
+
+// check validate the exam and the subject, teacherID not zero must be
+// assigned to the subject in the class
+func (c *ClassScore) check(teacherID int64) error {
+	if _, err := Cm.GetInfo(c.ClassID); err != nil {
+		return err
+	}
+	if !Sm.IsExist(c.SubjectID) {
+		return errSubject
+	}
+	if _, _, err := splitTermID(c.TermID); err != nil {
+		return err
+	}
+	if c.Exam <= 0 {
+		return errors.New("invalid exam")
+	}
+	if len(c.Scores) == 0 {
+		return errInvalidInput
+	}
+	if teacherID != 0 && !Cm.IsInstructor(c.ClassID, c.SubjectID, teacherID) {
+		return ErrNotInstructor
+	}
+	return nil
+}
+
+// AddClassScore save scores of the class in one transaction, nothing is saved
+// if any row is rejected. teacherID not zero must teach the subject in the class
+func (ssm *StudentScoreManager) AddClassScore(req *ClassScore, teacherID int64) (*ImportResult, error) {
+	err := req.check(teacherID)
+	if err != nil {
+		logs.Debug("[StudentScoreManager::AddClassScore] invalid request", "err", err)
+		return nil, err
+	}
+
+	ret := &ImportResult{Total: len(req.Scores), Errors: []ImportError{}}
+	seen := make(map[int64]bool)
+	list := StudentScoreList{}
+	for k, v := range req.Scores {
+		row := v.Row
+		if row == 0 {
+			row = k + 1
+		}
+
+		var s *StudentInfo
+		if v.StudentID != 0 {
+			s, err = Um.GetUser(v.StudentID)
+		} else {
+			s, err = Um.GetStudentByRegisterNumber(v.RegisterID)
+		}
+		if err == nil && s.ClassID != req.ClassID {
+			err = errors.New("student not in class")
+		} else if err == nil && seen[s.StudentID] {
+			err = errors.New("student duplicated")
+		} else if err == nil && (v.Score < base.MinScore || v.Score > base.MaxScore) {
+			err = errors.New("invalid score")
+		}
+		if err != nil {
+			ret.Errors = append(ret.Errors, ImportError{Row: row, RegisterID: v.RegisterID, Msg: err.Error()})
+			continue
+		}
+
+		seen[s.StudentID] = true
+		list = append(list, StudentScore{
+			StudentID: s.StudentID,
+			TermID:    req.TermID,
+			Exam:      req.Exam,
+			Scores:    ScorePairList{{SubjectID: req.SubjectID, Score: v.Score}},
+		})
+	}
+	ret.Valid = len(list)
+
+	if len(ret.Errors) != 0 {
+		logs.Info("[StudentScoreManager::AddClassScore] rejected", "classID", req.ClassID, "errors", len(ret.Errors))
+		return ret, nil
+	}
+
+	err = ssm.store.UpsertScores(list)
+	if err != nil {
+		logs.Warn("[StudentScoreManager::AddClassScore] UpsertScores failed", "err", err)
+		return nil, err
+	}
+
+	ssm.mutex.Lock()
+	defer ssm.mutex.Unlock()
+	for _, v := range list {
+		ssm.put(v)
+	}
+	ret.Imported = len(list)
+
+	logs.Info("[StudentScoreManager::AddClassScore] scores saved", "classID", req.ClassID, "subjectID", req.SubjectID, "count", ret.Imported)
+	return ret, nil
+}
+
+// put merge the record into cache, caller holds mutex
+func (ssm *StudentScoreManager) put(r StudentScore) error {
+	year, term, err := splitTermID(r.TermID)
+	if err != nil {
+		return err
+	}
+	if ssm.score == nil {
+		ssm.score = make(map[int64]YearScoreList)
+	}
+
+	list := ssm.score[r.StudentID]
+	yi := -1
+	for k, v := range list {
+		if v.Year == year {
+			yi = k
+		}
+	}
+	if yi < 0 {
+		list = append(list, YearScore{Year: year})
+		yi = len(list) - 1
+	}
+
+	ts := &list[yi].TermScores[term-1]
+	ts.TermID = r.TermID
+	ei := -1
+	for k, v := range ts.ExamsScores {
+		if v.Exam == r.Exam {
+			ei = k
+		}
+	}
+	if ei < 0 {
+		ts.ExamsScores = append(ts.ExamsScores, ExamScore{Exam: r.Exam})
+		ei = len(ts.ExamsScores) - 1
+	}
+
+	es := &ts.ExamsScores[ei]
+	for _, v := range r.Scores {
+		found := false
+		for k := range es.Scores {
+			if es.Scores[k].SubjectID == v.SubjectID {
+				es.Scores[k].Score = v.Score
+				found = true
+			}
+		}
+		if !found {
+			es.Scores = append(es.Scores, v)
+		}
+	}
+
+	ssm.score[r.StudentID] = list
+	return nil
+}
+
+func (ssm *StudentScoreManager) getStudentScore(studentID int64) (StudentScore, error) {
+	ssm.mutex.Lock()
+	defer ssm.mutex.Unlock()
+
+	item := StudentScore{StudentID: studentID}
 	score, ok := ssm.score[studentID]
 	if !ok {
 		return item, errNotExist
@@ -45,30 +325,26 @@ func (ssm StudentScoreManager) getStudentScore(studentID int64) (StudentScore, e
 	return item, nil
 }
 
-func (ssm StudentScoreManager) getClassScore(classID int) (StudentScoreList, error) {
+// GetClassScore current exam score of students in class
+func (ssm *StudentScoreManager) GetClassScore(classID int) (StudentScoreList, error) {
+	return ssm.getClassScore(classID)
+}
+
+func (ssm *StudentScoreManager) getClassScore(classID int) (StudentScoreList, error) {
 	ret := StudentScoreList{}
-	classInfo, err := Cm.GetInfo(classID)
+	_, err := Cm.GetInfo(classID)
 	if err != nil {
 		return ret, err
 	}
-	students := classInfo.StudentList
 
-	ret = ssm.getCurrentScore(students)
+	ret = ssm.getCurrentScore(Um.getClassStudentList(classID))
 	return ret, nil
 }
 
-func (ssm StudentScoreManager) getGradeScore(grade int) (StudentScoreList, error) {
-	studentID, err := Um.getStudentList(grade)
-	if err != nil {
-		return nil, err
-	}
-
-	ret := ssm.getCurrentScore(studentID)
-
-	return ret, nil
-}
+func (ssm *StudentScoreManager) getCurrentScore(sid []int64) StudentScoreList {
+	ssm.mutex.Lock()
+	defer ssm.mutex.Unlock()
 
-func (ssm StudentScoreManager) getCurrentScore(sid []int64) StudentScoreList {
 	ret := StudentScoreList{}
 	for _, s := range sid {
 		item := StudentScore{StudentID: s}
@@ -102,3 +378,223 @@ func (ssm StudentScoreManager) getCurrentScore(sid []int64) StudentScoreList {
 
 	return ret
 }
+
+// exam the current one, or the term and exam given
+func (ssm *StudentScoreManager) exam(current bool, termID, exam int) (int, int, error) {
+	if current {
+		ssm.mutex.Lock()
+		termID, exam = ssm.currentTerm, ssm.currentExam
+		ssm.mutex.Unlock()
+	}
+	if _, _, err := splitTermID(termID); err != nil {
+		return 0, 0, err
+	}
+	if exam <= 0 {
+		return 0, 0, errors.New("invalid exam")
+	}
+	return termID, exam, nil
+}
+
+// examScore scores of the student in the exam, caller holds mutex
+func (ssm *StudentScoreManager) examScore(studentID int64, termID, exam int) ScorePairList {
+	year, term, err := splitTermID(termID)
+	if err != nil {
+		return nil
+	}
+	for _, sy := range ssm.score[studentID] {
+		if sy.Year != year {
+			continue
+		}
+		for _, se := range sy.TermScores[term-1].ExamsScores {
+			if se.Exam == exam {
+				return append(ScorePairList{}, se.Scores...)
+			}
+		}
+	}
+	return nil
+}
+
+// Rank scores of the exam with class rank and grade rank, ranked by total or
+// by the subject if set, ties share the rank (1, 2, 2, 4). students without
+// score are left out. the exam is the current one unless term and exam are set
+func (ssm *StudentScoreManager) Rank(f ScoreFilter) (RankList, error) {
+	termID, exam, err := ssm.exam(f.OnlyCurrent || f.TermID == 0, f.TermID, f.Exam)
+	if err != nil {
+		return nil, err
+	}
+
+	// ranks are always computed over the whole grade
+	grade := f.Grade
+	if f.StudentID != 0 && f.ClassID == 0 {
+		s, err := Um.GetUser(f.StudentID)
+		if err != nil {
+			return nil, err
+		}
+		f.ClassID = s.ClassID
+	}
+	if f.ClassID != 0 {
+		c, err := Cm.GetInfo(f.ClassID)
+		if err != nil {
+			return nil, err
+		}
+		grade = c.Grade
+	}
+	if grade <= 0 {
+		return nil, errInvalidParam
+	}
+
+	students, err := Um.getStudentList(grade)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := RankList{}
+	ssm.mutex.Lock()
+	for _, id := range students {
+		s, err := Um.GetUser(id)
+		if err != nil {
+			continue
+		}
+		item := RankItem{StudentID: id, RegisterID: s.RegisterID, Name: s.RealName, ClassID: s.ClassID, Scores: ScorePairList{}}
+		for _, v := range ssm.examScore(id, termID, exam) {
+			if f.SubjectID != 0 && v.SubjectID != f.SubjectID {
+				continue
+			}
+			item.Total += v.Score
+			item.Scores = append(item.Scores, v)
+		}
+		if len(item.Scores) == 0 {
+			continue
+		}
+		ret = append(ret, item)
+	}
+	ssm.mutex.Unlock()
+
+	sort.Sort(ret)
+	type last struct{ count, total, rank int }
+	classes := make(map[int]*last)
+	for k := range ret {
+		v := &ret[k]
+		if k == 0 || v.Total != ret[k-1].Total {
+			v.GradeRank = k + 1
+		} else {
+			v.GradeRank = ret[k-1].GradeRank
+		}
+
+		c, ok := classes[v.ClassID]
+		if !ok {
+			c = &last{}
+			classes[v.ClassID] = c
+		}
+		c.count++
+		if c.count == 1 || v.Total != c.total {
+			c.rank = c.count
+		}
+		c.total = v.Total
+		v.ClassRank = c.rank
+	}
+
+	list := RankList{}
+	for _, v := range ret {
+		if f.StudentID != 0 && v.StudentID != f.StudentID {
+			continue
+		}
+		if f.ClassID != 0 && v.ClassID != f.ClassID {
+			continue
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+// TrendPoint total and ranks of the student in an exam, rank change is against
+// the previous exam, positive for moving up
+type TrendPoint struct {
+	TermID          int           `json:"term_id"`
+	Exam            int           `json:"exam"`
+	Total           int           `json:"total"`
+	Scores          ScorePairList `json:"scores"`
+	ClassRank       int           `json:"class_rank"`
+	GradeRank       int           `json:"grade_rank"`
+	ClassRankChange int           `json:"class_rank_change"`
+	GradeRankChange int           `json:"grade_rank_change"`
+}
+
+// SubjectPoint score of a subject in an exam
+type SubjectPoint struct {
+	TermID int `json:"term_id"`
+	Exam   int `json:"exam"`
+	Score  int `json:"score"`
+}
+
+// SubjectTrend score series of a subject
+type SubjectTrend struct {
+	SubjectID int            `json:"subject_id"`
+	Subject   string         `json:"subject"`
+	Points    []SubjectPoint `json:"points"`
+}
+
+// ScoreTrend scores of the student across exams, in order of term and exam
+type ScoreTrend struct {
+	StudentID int64          `json:"student_id"`
+	Exams     []TrendPoint   `json:"exams"`
+	Subjects  []SubjectTrend `json:"subjects"`
+}
+
+// Trend score series of the student across all exams with rank movement,
+// ranks are among the current class and grade of the student
+func (ssm *StudentScoreManager) Trend(studentID int64) (*ScoreTrend, error) {
+	if !Um.IsExist(studentID) {
+		return nil, errNotExist
+	}
+
+	ret := &ScoreTrend{StudentID: studentID, Exams: []TrendPoint{}, Subjects: []SubjectTrend{}}
+	ssm.mutex.Lock()
+	for _, sy := range ssm.score[studentID] {
+		for _, st := range sy.TermScores {
+			for _, se := range st.ExamsScores {
+				p := TrendPoint{TermID: st.TermID, Exam: se.Exam, Scores: append(ScorePairList{}, se.Scores...)}
+				for _, v := range se.Scores {
+					p.Total += v.Score
+				}
+				ret.Exams = append(ret.Exams, p)
+			}
+		}
+	}
+	ssm.mutex.Unlock()
+
+	sort.Slice(ret.Exams, func(i, j int) bool {
+		if ret.Exams[i].TermID != ret.Exams[j].TermID {
+			return ret.Exams[i].TermID < ret.Exams[j].TermID
+		}
+		return ret.Exams[i].Exam < ret.Exams[j].Exam
+	})
+
+	subjects := make(map[int]int)
+	for k := range ret.Exams {
+		p := &ret.Exams[k]
+		sort.Slice(p.Scores, func(i, j int) bool { return p.Scores[i].SubjectID < p.Scores[j].SubjectID })
+
+		// not ranked if the student is in no class
+		list, err := ssm.Rank(ScoreFilter{StudentID: studentID, TermID: p.TermID, Exam: p.Exam})
+		if err == nil && len(list) == 1 {
+			p.ClassRank, p.GradeRank = list[0].ClassRank, list[0].GradeRank
+		}
+		if k > 0 && p.ClassRank != 0 && ret.Exams[k-1].ClassRank != 0 {
+			p.ClassRankChange = ret.Exams[k-1].ClassRank - p.ClassRank
+			p.GradeRankChange = ret.Exams[k-1].GradeRank - p.GradeRank
+		}
+
+		for _, v := range p.Scores {
+			i, ok := subjects[v.SubjectID]
+			if !ok {
+				i = len(ret.Subjects)
+				subjects[v.SubjectID] = i
+				ret.Subjects = append(ret.Subjects, SubjectTrend{SubjectID: v.SubjectID, Subject: Sm.getSubjectName(v.SubjectID)})
+			}
+			ret.Subjects[i].Points = append(ret.Subjects[i].Points, SubjectPoint{TermID: p.TermID, Exam: p.Exam, Score: v.Score})
+		}
+	}
+	sort.Slice(ret.Subjects, func(i, j int) bool { return ret.Subjects[i].SubjectID < ret.Subjects[j].SubjectID })
+	return ret, nil
+}
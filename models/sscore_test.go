@@ -0,0 +1,313 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+
+	"github.com/arong/dean/base"
+)
+
+func TestStudentScoreManager_Init(t *testing.T) {
+	ssm := StudentScoreManager{}
+	ssm.Init(StudentScoreList{
+		{StudentID: 1, TermID: 20181, Exam: 2, Scores: ScorePairList{{SubjectID: 1, Score: 90}}},
+		{StudentID: 1, TermID: 20182, Exam: 1, Scores: ScorePairList{{SubjectID: 1, Score: 80}}},
+		{StudentID: 1, TermID: 20182, Exam: 1, Scores: ScorePairList{{SubjectID: 2, Score: 70}}},
+		{StudentID: 2, TermID: 20182, Exam: 1, Scores: ScorePairList{{SubjectID: 1, Score: 60}}},
+		{StudentID: 2, TermID: 20183, Exam: 9, Scores: ScorePairList{{SubjectID: 1, Score: 60}}},
+	})
+
+	if ssm.currentYear != 2018 || ssm.currentTerm != 20182 || ssm.currentExam != 1 {
+		t.Fatal("latest exam not current", ssm.currentYear, ssm.currentTerm, ssm.currentExam)
+	}
+
+	ret := ssm.getCurrentScore([]int64{1, 2, 3})
+	if len(ret) != 2 || len(ret[0].Scores) != 2 || len(ret[1].Scores) != 1 {
+		t.Fatal("current score mismatch", ret)
+	}
+
+	if err := ssm.SetCurrent(20180, 1); err != ErrTerm {
+		t.Fatal("invalid term accepted", err)
+	}
+	if err := ssm.SetCurrent(20181, 2); err != nil {
+		t.Fatal(err)
+	}
+	s, err := ssm.getStudentScore(1)
+	if err != nil || len(s.Scores) != 1 || s.Scores[0].Score != 90 {
+		t.Fatal("score of the exam set not found", s, err)
+	}
+}
+
+func TestStudentScoreManager_AddRecord(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := NewMockScoreStore(mockCtrl)
+	ssm := StudentScoreManager{store: mockStore}
+	ssm.Init(nil)
+	if err := ssm.SetCurrent(20191, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ssm.AddRecord(StudentScore{StudentID: 1, TermID: 2019, Exam: 1}, 0); err != ErrTerm {
+		t.Fatal("invalid term accepted", err)
+	}
+
+	r := StudentScore{StudentID: 1, TermID: 20191, Exam: 1, Scores: ScorePairList{{SubjectID: 1, Score: 90}, {SubjectID: 2, Score: 80}}}
+	mockStore.EXPECT().UpsertScores(StudentScoreList{r}).Return(errors.New("sank your ship"))
+	if err := ssm.AddRecord(r, 0); err == nil {
+		t.Fatal("logic error")
+	}
+	if _, err := ssm.getStudentScore(1); err != errNotExist {
+		t.Fatal("cache changed on failure", err)
+	}
+
+	mockStore.EXPECT().UpsertScores(gomock.Any()).Return(nil).Times(2)
+	if err := ssm.AddRecord(r, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// same subject is overwritten
+	if err := ssm.AddRecord(StudentScore{StudentID: 1, TermID: 20191, Exam: 1, Scores: ScorePairList{{SubjectID: 2, Score: 85}}}, 0); err != nil {
+		t.Fatal(err)
+	}
+	s, err := ssm.getStudentScore(1)
+	if err != nil || len(s.Scores) != 2 || s.Scores[1].Score != 85 {
+		t.Fatal("score not overwritten", s, err)
+	}
+
+	// teacher enters only the subject taught in the class of the student
+	Cm = classManager{idMap: map[int]*Class{1: {ID: 1, TeacherList: InstructorList{{TeacherID: 10, SubjectID: 1}}}}}
+	defer func() { Cm = classManager{} }()
+	Um.Init(map[int64]*StudentInfo{1: {StudentID: 1, ClassID: 1}})
+	defer Um.Init(nil)
+
+	if err = ssm.AddRecord(r, 10); err != ErrNotInstructor {
+		t.Fatal("score of other subject added", err)
+	}
+	if err = ssm.AddRecord(StudentScore{StudentID: 2, TermID: 20191, Exam: 1, Scores: ScorePairList{{SubjectID: 1, Score: 60}}}, 10); err == nil {
+		t.Fatal("score of unknown student added")
+	}
+	mockStore.EXPECT().UpsertScores(gomock.Any()).Return(nil)
+	if err = ssm.AddRecord(StudentScore{StudentID: 1, TermID: 20191, Exam: 1, Scores: ScorePairList{{SubjectID: 1, Score: 60}}}, 10); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStudentScoreManager_AddClassScore(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	Sm.Init(SubjectList{{ID: 1, Name: "数学", Key: "math"}, {ID: 2, Name: "语文", Key: "chinese"}})
+	defer Sm.Init(nil)
+	Cm = classManager{idMap: map[int]*Class{
+		1: {ID: 1, TeacherList: InstructorList{{TeacherID: 10, SubjectID: 1}}},
+		2: {ID: 2},
+	}}
+	defer func() { Cm = classManager{} }()
+	Um.Init(map[int64]*StudentInfo{
+		1: {StudentID: 1, RegisterID: "2019001", ClassID: 1},
+		2: {StudentID: 2, RegisterID: "2019002", ClassID: 1},
+		3: {StudentID: 3, RegisterID: "2019003", ClassID: 2},
+	})
+	defer Um.Init(nil)
+
+	mockStore := NewMockScoreStore(mockCtrl)
+	ssm := StudentScoreManager{store: mockStore}
+	ssm.Init(nil)
+
+	req := &ClassScore{ClassID: 1, SubjectID: 1, TermID: 20191, Exam: 1, Scores: []ClassScoreRow{
+		{StudentID: 1, Score: 90},
+		{RegisterID: "2019002", Score: 80},
+	}}
+
+	// only the instructor of the subject in the class
+	if _, err := ssm.AddClassScore(req, 11); err != ErrNotInstructor {
+		t.Fatal("scores added by other teacher", err)
+	}
+	req.SubjectID = 2
+	if _, err := ssm.AddClassScore(req, 10); err != ErrNotInstructor {
+		t.Fatal("scores added for other subject", err)
+	}
+	req.SubjectID = 1
+
+	bad := *req
+	bad.Scores = []ClassScoreRow{
+		{StudentID: 1, Score: 90},
+		{Row: 3, RegisterID: "2019003", Score: 80},
+		{Row: 4, RegisterID: "2019001", Score: 70},
+		{Row: 5, RegisterID: "2019002", Score: base.MaxScore + 1},
+		{Row: 6, RegisterID: "2019009", Score: 60},
+	}
+	ret, err := ssm.AddClassScore(&bad, 10)
+	if err != nil || ret.Imported != 0 || ret.Valid != 1 || len(ret.Errors) != 4 {
+		t.Fatal("invalid rows not rejected", ret, err)
+	}
+	for k, v := range []int{3, 4, 5, 6} {
+		if ret.Errors[k].Row != v {
+			t.Fatal("row number mismatch", ret.Errors)
+		}
+	}
+
+	mockStore.EXPECT().UpsertScores(gomock.Any()).Return(errors.New("sank your ship"))
+	if _, err = ssm.AddClassScore(req, 10); err == nil {
+		t.Fatal("logic error")
+	}
+	if _, err = ssm.getStudentScore(1); err != errNotExist {
+		t.Fatal("cache changed on failure", err)
+	}
+
+	// dean office is not restricted to the assignment
+	mockStore.EXPECT().UpsertScores(gomock.Len(2)).Return(nil)
+	ret, err = ssm.AddClassScore(req, 0)
+	if err != nil || ret.Imported != 2 {
+		t.Fatal("add class score failed", ret, err)
+	}
+	ssm.SetCurrent(20191, 1)
+	scores := ssm.getCurrentScore([]int64{1, 2})
+	if len(scores) != 2 || scores[1].Scores[0].Score != 80 {
+		t.Fatal("score mismatch", scores)
+	}
+}
+
+func TestStudentScoreManager_Rank(t *testing.T) {
+	Cm = classManager{idMap: map[int]*Class{
+		1: {ID: 1, Filter: Filter{Grade: 1, Index: 1}},
+		2: {ID: 2, Filter: Filter{Grade: 1, Index: 2}},
+		3: {ID: 3, Filter: Filter{Grade: 2, Index: 1}},
+	}}
+	defer func() { Cm = classManager{} }()
+	Um.Init(map[int64]*StudentInfo{
+		1: {StudentID: 1, ClassID: 1},
+		2: {StudentID: 2, ClassID: 1},
+		3: {StudentID: 3, ClassID: 2},
+		4: {StudentID: 4, ClassID: 1},
+		5: {StudentID: 5, ClassID: 3},
+		6: {StudentID: 6, ClassID: 2},
+	})
+	defer Um.Init(nil)
+
+	score := func(id int64, math, chinese int) StudentScore {
+		return StudentScore{StudentID: id, TermID: 20191, Exam: 1, Scores: ScorePairList{{SubjectID: 1, Score: math}, {SubjectID: 2, Score: chinese}}}
+	}
+	ssm := StudentScoreManager{}
+	ssm.Init(StudentScoreList{
+		score(1, 90, 90),  // 180
+		score(2, 100, 70), // 170
+		score(3, 85, 95),  // 180
+		score(4, 80, 90),  // 170
+		score(5, 100, 100),
+		{StudentID: 6, TermID: 20191, Exam: 2, Scores: ScorePairList{{SubjectID: 1, Score: 100}}},
+	})
+
+	if _, err := ssm.Rank(ScoreFilter{OnlyCurrent: true}); err != errInvalidParam {
+		t.Fatal("rank without grade", err)
+	}
+
+	// current exam is the latest one, only student 6 took it
+	ret, err := ssm.Rank(ScoreFilter{OnlyCurrent: true, Grade: 1})
+	if err != nil || len(ret) != 1 || ret[0].StudentID != 6 {
+		t.Fatal("current exam not used", ret, err)
+	}
+
+	ret, err = ssm.Rank(ScoreFilter{Grade: 1, TermID: 20191, Exam: 1})
+	if err != nil || len(ret) != 4 {
+		t.Fatal("rank failed", ret, err)
+	}
+	expect := []struct {
+		id               int64
+		total, grade, cl int
+	}{
+		{1, 180, 1, 1}, {3, 180, 1, 1}, {2, 170, 3, 2}, {4, 170, 3, 2},
+	}
+	for k, v := range expect {
+		if ret[k].StudentID != v.id || ret[k].Total != v.total || ret[k].GradeRank != v.grade || ret[k].ClassRank != v.cl {
+			t.Fatal("rank mismatch", k, ret[k])
+		}
+	}
+
+	// by subject, grade ranks still cover the whole grade
+	ret, err = ssm.Rank(ScoreFilter{ClassID: 2, SubjectID: 1, TermID: 20191, Exam: 1})
+	if err != nil || len(ret) != 1 || ret[0].Total != 85 || ret[0].GradeRank != 3 || ret[0].ClassRank != 1 {
+		t.Fatal("subject rank mismatch", ret, err)
+	}
+
+	ret, err = ssm.Rank(ScoreFilter{StudentID: 4, TermID: 20191, Exam: 1})
+	if err != nil || len(ret) != 1 || ret[0].GradeRank != 3 || ret[0].ClassRank != 2 {
+		t.Fatal("student rank mismatch", ret, err)
+	}
+
+	// student filter does not escape the class
+	ret, err = ssm.Rank(ScoreFilter{ClassID: 2, StudentID: 4, TermID: 20191, Exam: 1})
+	if err != nil || len(ret) != 0 {
+		t.Fatal("student of other class returned", ret, err)
+	}
+}
+
+func TestStudentScoreManager_Trend(t *testing.T) {
+	Sm.Init(SubjectList{{ID: 1, Name: "数学", Key: "math"}, {ID: 2, Name: "语文", Key: "chinese"}})
+	defer Sm.Init(nil)
+	Cm = classManager{idMap: map[int]*Class{
+		1: {ID: 1, Filter: Filter{Grade: 1, Index: 1}},
+		2: {ID: 2, Filter: Filter{Grade: 1, Index: 2}},
+	}}
+	defer func() { Cm = classManager{} }()
+	Um.Init(map[int64]*StudentInfo{
+		1: {StudentID: 1, ClassID: 1},
+		2: {StudentID: 2, ClassID: 1},
+		3: {StudentID: 3, ClassID: 2},
+		4: {StudentID: 4},
+	})
+	defer Um.Init(nil)
+
+	score := func(id int64, termID, exam, math, chinese int) StudentScore {
+		return StudentScore{StudentID: id, TermID: termID, Exam: exam, Scores: ScorePairList{{SubjectID: 2, Score: chinese}, {SubjectID: 1, Score: math}}}
+	}
+	ssm := StudentScoreManager{}
+	ssm.Init(StudentScoreList{
+		// second term recorded first, the series is still in order
+		score(1, 20192, 1, 95, 95),
+		score(2, 20192, 1, 80, 80),
+		score(3, 20192, 1, 70, 70),
+		score(1, 20191, 1, 60, 60),
+		score(2, 20191, 1, 80, 80),
+		score(3, 20191, 1, 90, 90),
+		score(1, 20191, 2, 80, 70),
+		score(3, 20191, 2, 90, 90),
+		score(4, 20191, 1, 100, 100),
+	})
+
+	if _, err := ssm.Trend(9); err != errNotExist {
+		t.Fatal("trend of unknown student", err)
+	}
+
+	ret, err := ssm.Trend(1)
+	if err != nil || len(ret.Exams) != 3 || len(ret.Subjects) != 2 {
+		t.Fatal("trend failed", ret, err)
+	}
+	expect := []struct {
+		termID, exam, total, class, grade, classChange, gradeChange int
+	}{
+		{20191, 1, 120, 2, 3, 0, 0},
+		{20191, 2, 150, 1, 2, 1, 1},
+		{20192, 1, 190, 1, 1, 0, 1},
+	}
+	for k, v := range expect {
+		p := ret.Exams[k]
+		if p.TermID != v.termID || p.Exam != v.exam || p.Total != v.total || p.ClassRank != v.class || p.GradeRank != v.grade ||
+			p.ClassRankChange != v.classChange || p.GradeRankChange != v.gradeChange {
+			t.Fatal("trend point mismatch", k, p)
+		}
+	}
+	if ret.Subjects[0].Subject != "数学" || len(ret.Subjects[0].Points) != 3 || ret.Subjects[0].Points[1].Score != 80 || ret.Subjects[1].Points[1].Score != 70 {
+		t.Fatal("subject series mismatch", ret.Subjects)
+	}
+
+	// student in no class is not ranked
+	ret, err = ssm.Trend(4)
+	if err != nil || len(ret.Exams) != 1 || ret.Exams[0].Total != 200 || ret.Exams[0].GradeRank != 0 {
+		t.Fatal("trend without class mismatch", ret, err)
+	}
+}
@@ -18,19 +18,52 @@ import (
 // Ma mysql agent
 var Ma mysqlAgent
 
+// supported storage drivers
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite3"
+)
+
+// dataStore is implemented by every storage backend
+type dataStore interface {
+	SubjectStore
+	TeacherStore
+	ClassStore
+	StudentStore
+	QuestionnaireStore
+	PasswordStore
+	ScoreStore
+	LoadAllData() error
+}
+
 const (
 	defaultBirthday = "0000-00-00"
 )
 
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 type mysqlAgent struct {
 	db *sql.DB
 }
 
+// source data source name of the configured driver
+func (c *DBConfig) source() string {
+	if c.Driver == DriverSQLite {
+		// example: "file:./dean.db?_busy_timeout=5000&_journal_mode=WAL"
+		return "file:" + c.Path + "?_busy_timeout=5000&_journal_mode=WAL"
+	}
+	// example: "root:123456@tcp(localhost:3306)/lflss?charset=utf8"
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8", c.User, c.Password, c.Host, c.Port, c.DBName)
+}
+
 func (ma *mysqlAgent) Init(conf *DBConfig) {
 	var err error
-	// example: "root:123456@tcp(localhost:3306)/lflss?charset=utf8"
-	path := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8", conf.User, conf.Password, conf.Host, conf.Port, conf.DBName)
-	ma.db, err = sql.Open("mysql", path)
+	ma.db, err = sql.Open(DriverMySQL, conf.source())
 	if err != nil {
 		panic("cannot connect to mysql")
 	}
@@ -159,7 +192,7 @@ func (ma *mysqlAgent) LoadAllData() error {
 	// load students
 	userMap := make(map[int64]*StudentInfo)
 	{
-		rows, err := ma.db.Query("SELECT iUserID,vName,vRegistNumber,eGender,iClassID FROM tbStudent WHERE eStatus = 1;")
+		rows, err := ma.db.Query("SELECT iUserID,vName,vRegistNumber,eGender,iClassID,vMobile,vAddress,dtBirthday FROM tbStudent WHERE eStatus = 1;")
 		if err != nil {
 			logs.Error("[LoadAllData] failed to load tbStudent", "err", err)
 			return err
@@ -168,20 +201,54 @@ func (ma *mysqlAgent) LoadAllData() error {
 
 		for rows.Next() {
 			u := StudentInfo{}
-			err = rows.Scan(&u.StudentID, &u.RealName, &u.RegisterID, &u.Gender, &u.ClassID)
+			err = rows.Scan(&u.StudentID, &u.RealName, &u.RegisterID, &u.Gender, &u.ClassID, &u.Mobile, &u.Address, &u.Birthday)
 			if err != nil {
+				logs.Warn("[LoadAllData] data error at tbStudent", "err", err)
 				continue
 			}
+			if u.Birthday != defaultBirthday {
+				birth, err := time.Parse(base.DateFormat, u.Birthday)
+				if err != nil {
+					logs.Warn("[LoadAllData] data error at tbStudent", "birthday", u.Birthday, "err", err)
+					continue
+				}
+				u.Age = age.Age(birth)
+			} else {
+				u.Birthday = ""
+			}
 			userMap[u.StudentID] = &u
 		}
 	}
 	// init student manager
 	Um.Init(userMap)
+	Cm.SetStudents(userMap)
+
+	// load student score
+	scoreList := StudentScoreList{}
+	{
+		rows, err := ma.db.Query("SELECT iStudentID,iTermID,eExam,iSubjectID,iScore FROM tbStudentScore;")
+		if err != nil {
+			logs.Error("[LoadAllData] failed to load tbStudentScore", "err", err)
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			tmp := StudentScore{Scores: ScorePairList{{}}}
+			err = rows.Scan(&tmp.StudentID, &tmp.TermID, &tmp.Exam, &tmp.Scores[0].SubjectID, &tmp.Scores[0].Score)
+			if err != nil {
+				logs.Error("scan tbStudentScore failed", err)
+				continue
+			}
+			scoreList = append(scoreList, tmp)
+		}
+	}
+	SSM.Init(scoreList)
 
 	// init access control
 	loginMap := make(map[LoginKey]*LoginInfo)
 	{
-		rows, err := ma.db.Query("SELECT iUserID,eType,vLoginName,vPassword FROM tbPassword;")
+		rows, err := ma.db.Query("SELECT iUserID,eType,vLoginName,vPassword,iRole,eStatus,bMustChange FROM tbPassword;")
 		if err != nil {
 			logs.Error("[LoadAllData] failed to load tbPassword", "err", err)
 			return err
@@ -190,7 +257,7 @@ func (ma *mysqlAgent) LoadAllData() error {
 
 		for rows.Next() {
 			tmp := LoginInfo{}
-			err = rows.Scan(&tmp.ID, &tmp.UserType, &tmp.LoginName, &tmp.Password)
+			err = rows.Scan(&tmp.ID, &tmp.UserType, &tmp.LoginName, &tmp.Password, &tmp.Role, &tmp.Status, &tmp.MustChange)
 			if err != nil {
 				logs.Error("scan failed", err)
 				continue
@@ -198,12 +265,14 @@ func (ma *mysqlAgent) LoadAllData() error {
 			loginMap[LoginKey{UserType: tmp.UserType, LoginName: tmp.LoginName}] = &tmp
 		}
 	}
+	Ac.loginMutex.Lock()
 	Ac.loginMap = loginMap
+	Ac.loginMutex.Unlock()
 
 	// init questionnaire
 	questionMap := make(map[int]*QuestionnaireInfo)
 	{
-		rows, err := ma.db.Query("SELECT iQuestionnaireID,vTitle,dtStartTime,dtStopTime,eDraftStatus,vEditorName FROM tbQuestionnaire;")
+		rows, err := ma.db.Query("SELECT iQuestionnaireID,vTitle,dtStartTime,dtStopTime,eDraftStatus,vEditorName,bAllowAmend FROM tbQuestionnaire;")
 		if err != nil {
 			logs.Error("[LoadAllData] failed to load tbQuestionnaire", "err", err)
 			return err
@@ -212,11 +281,21 @@ func (ma *mysqlAgent) LoadAllData() error {
 
 		for rows.Next() {
 			tmp := QuestionnaireInfo{}
-			err = rows.Scan(&tmp.QuestionnaireID, &tmp.Title, &tmp.StartTime, &tmp.StopTime, &tmp.Status, &tmp.Editor)
+			amend := 0
+			err = rows.Scan(&tmp.QuestionnaireID, &tmp.Title, &tmp.StartTime, &tmp.StopTime, &tmp.Status, &tmp.Editor, &amend)
 			if err != nil {
 				logs.Error("scan tbQuestionnaire failed", err)
 				continue
 			}
+			if amend == 1 {
+				tmp.AllowAmend = true
+			}
+			if t, err := base.ParseDateTime(tmp.StartTime); err == nil {
+				tmp.startTime = t
+			}
+			if t, err := base.ParseDateTime(tmp.StopTime); err == nil {
+				tmp.stopTime = t
+			}
 			decoded, err := base64.StdEncoding.DecodeString(tmp.Title)
 			if err != nil {
 				continue
@@ -276,6 +355,50 @@ func (ma *mysqlAgent) LoadAllData() error {
 			QuestionnaireManager.questions[tmp.QuestionID] = &tmp
 		}
 	}
+
+	// rebuild teacher score from submissions
+	{
+		rows, err := ma.db.Query("SELECT iQuestionnaireID,iStudentID,iTeacherID,iGrade,iIndex,vContent FROM tbSubmission WHERE eStatus=1;")
+		if err != nil {
+			logs.Error("[LoadAllData] failed to load tbSubmission", "err", err)
+			return err
+		}
+		defer rows.Close()
+
+		count := 0
+		for rows.Next() {
+			var questionnaireID int
+			var studentID int64
+			tmp := TeacherAnswer{}
+			source := sourceMeta{}
+			buff := ""
+			err = rows.Scan(&questionnaireID, &studentID, &tmp.TeacherID, &source.Grade, &source.Index, &buff)
+			if err != nil {
+				logs.Error("[LoadAllData] scan tbSubmission failed", err)
+				continue
+			}
+
+			decoded, err := base64.StdEncoding.DecodeString(buff)
+			if err != nil {
+				logs.Warn("[LoadAllData] tbSubmission.Content data error", "err", err)
+				continue
+			}
+			err = json.Unmarshal(decoded, &tmp.Answers)
+			if err != nil {
+				logs.Warn("[LoadAllData] invalid answer data", "err", err)
+				continue
+			}
+
+			q, ok := QuestionnaireManager.questionnaires[questionnaireID]
+			if !ok {
+				logs.Warn("[LoadAllData] questionnaire id not found", "id", questionnaireID)
+				continue
+			}
+			QuestionnaireManager.restore(q, studentID, tmp, source)
+			count++
+		}
+		logs.Info("total submission count is %d", count)
+	}
 	logs.Info("load data success")
 	return nil
 }
@@ -337,130 +460,140 @@ func (ma *mysqlAgent) DeleteTeacher(id []int64) error {
 
 // InsertClass insert class info
 func (ma *mysqlAgent) InsertClass(t *Class) error {
-	// Prepare statement for inserting data
-	stmtIns, err := ma.db.Prepare("INSERT INTO `tbClass` (`iGrade`, `iIndex`, `vName`,`iMasterID`,`iStartYear`,`eTerm`) VALUES (?,?,?,?,?,?);")
+	tx, err := ma.db.Begin()
 	if err != nil {
 		return err
 	}
-	defer stmtIns.Close()
 
-	resp, err := stmtIns.Exec(t.Grade, t.Index, t.Name, t.MasterID, t.Year, t.Term)
+	resp, err := tx.Exec("INSERT INTO `tbClass` (`iGrade`, `iIndex`, `vName`,`iMasterID`,`iStartYear`,`eTerm`) VALUES (?,?,?,?,?,?);",
+		t.Grade, t.Index, t.Name, t.MasterID, t.Year, t.Term)
 	if err != nil {
-		logs.Warn("execute sql failed", "err", err)
+		logs.Warn("[InsertClass] execute sql failed", "err", err)
+		tx.Rollback()
 		return err
 	}
 
 	id, err := resp.LastInsertId()
-	t.ID = int(id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// insert into class teacher relation table
-	{
-		stmtIns, err := ma.db.Prepare("INSERT INTO `tbClassTeacherRelation` (`iClassID`,`iSubjectID`, `iTeacherID`) VALUES (?,?,?);")
-		if err != nil {
-			return err
-		}
+	err = insertRelation(tx, int(id), t.TeacherList)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
 
-		for _, v := range t.TeacherList {
-			_, err = stmtIns.Exec(t.ID, v.SubjectID, v.TeacherID)
-			if err != nil {
-				logs.Warn("execute sql failed", "err", err)
-				return err
-			}
-		}
+	err = tx.Commit()
+	if err != nil {
+		return err
 	}
+	t.ID = int(id)
 	return nil
 }
 
 // UpdateClass update class info
 func (ma *mysqlAgent) UpdateClass(t *Class) error {
-	// insert into tbClass
-	{
-		stmtIns, err := ma.db.Prepare("UPDATE tbClass SET vName=?,iMasterID=?,iStartYear=?,eTerm=? WHERE iClassID=?;")
-		if err != nil {
-			return err
-		}
-		defer stmtIns.Close()
+	tx, err := ma.db.Begin()
+	if err != nil {
+		return err
+	}
 
-		_, err = stmtIns.Exec(t.Name, t.MasterID, t.Year, t.Term, t.ID)
-		if err != nil {
-			logs.Warn("execute sql failed", "err", err)
-			return err
-		}
+	_, err = tx.Exec("UPDATE tbClass SET vName=?,iMasterID=?,iStartYear=?,eTerm=? WHERE iClassID=?;", t.Name, t.MasterID, t.Year, t.Term, t.ID)
+	if err != nil {
+		logs.Warn("[UpdateClass] execute sql failed", "err", err)
+		tx.Rollback()
+		return err
 	}
 
 	// remove existing item
 	{
-		stmtIns, err := ma.db.Prepare("DELETE FROM `tbClassTeacherRelation` WHERE `iClassID`=? AND `iSubjectID`=? AND `iTeacherID`=?;")
+		stmtIns, err := tx.Prepare("DELETE FROM `tbClassTeacherRelation` WHERE `iClassID`=? AND `iSubjectID`=? AND `iTeacherID`=?;")
 		if err != nil {
+			tx.Rollback()
 			return err
 		}
+		defer stmtIns.Close()
 
 		for _, v := range t.RemoveList {
 			_, err = stmtIns.Exec(t.ID, v.SubjectID, v.TeacherID)
 			if err != nil {
-				logs.Warn("execute sql failed", "err", err)
+				logs.Warn("[UpdateClass] execute sql failed", "err", err)
+				tx.Rollback()
 				return err
 			}
 		}
 	}
 
 	// insert into class teacher relation table
-	{
-		stmtIns, err := ma.db.Prepare("INSERT INTO `tbClassTeacherRelation` (`iClassID`,`iSubjectID`, `iTeacherID`) VALUES (?,?,?);")
+	err = insertRelation(tx, t.ID, t.AddList)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertRelation(tx *sql.Tx, classID int, list InstructorList) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	stmtIns, err := tx.Prepare("INSERT INTO `tbClassTeacherRelation` (`iClassID`,`iSubjectID`, `iTeacherID`) VALUES (?,?,?);")
+	if err != nil {
+		return err
+	}
+	defer stmtIns.Close()
+
+	for _, v := range list {
+		_, err = stmtIns.Exec(classID, v.SubjectID, v.TeacherID)
 		if err != nil {
+			logs.Warn("[insertRelation] execute sql failed", "err", err)
 			return err
 		}
-
-		for _, v := range t.AddList {
-			_, err = stmtIns.Exec(t.ID, v.SubjectID, v.TeacherID)
-			if err != nil {
-				logs.Warn("execute sql failed", "err", err)
-				return err
-			}
-		}
 	}
 	return nil
 }
 
 // DeleteClass delete class info
 func (ma *mysqlAgent) DeleteClass(id int) error {
-	stmtIns, err := ma.db.Prepare("UPDATE tbClass set eStatus=? WHERE iClassID=?;")
+	tx, err := ma.db.Begin()
 	if err != nil {
 		return err
 	}
-	defer stmtIns.Close()
 
-	_, err = stmtIns.Exec(base.StatusDeleted, id)
+	_, err = tx.Exec("UPDATE tbClass set eStatus=? WHERE iClassID=?;", base.StatusDeleted, id)
 	if err != nil {
-		logs.Warn("execute sql failed", "err", err)
+		logs.Warn("[DeleteClass] execute sql failed", "err", err)
+		tx.Rollback()
 		return err
 	}
 
 	// remove teacher relation
-	{
-		stmtIns, err := ma.db.Prepare("DELETE FROM tbClassTeacherRelation WHERE iClassID=?;")
-		if err != nil {
-			return err
-		}
-		defer stmtIns.Close()
-
-		_, err = stmtIns.Exec(id)
-		if err != nil {
-			logs.Warn("execute sql failed", "err", err)
-			return err
-		}
+	_, err = tx.Exec("DELETE FROM tbClassTeacherRelation WHERE iClassID=?;", id)
+	if err != nil {
+		logs.Warn("[DeleteClass] execute sql failed", "err", err)
+		tx.Rollback()
+		return err
 	}
-	return nil
+	return tx.Commit()
 }
 
 // InsertStudent insert teacher info
 func (ma *mysqlAgent) InsertStudent(u *StudentInfo) (int64, error) {
-	stmt, err := ma.db.Prepare("INSERT INTO `tbStudent`(`vRegistNumber`, `vName`, `eGender`,`iClassID`,`vAddress`,`dtBirthday`) VALUES (?,?,?,?,?,?)")
+	stmt, err := ma.db.Prepare("INSERT INTO `tbStudent`(`vRegistNumber`, `vName`, `eGender`,`iClassID`,`vAddress`,`dtBirthday`,`vMobile`) VALUES (?,?,?,?,?,?,?)")
 	if err != nil {
 		logs.Error("[mysqlAgent::InsertUser] failed", "err")
 		return 0, err
 	}
 
-	rs, err := stmt.Exec(u.RegisterID, u.RealName, u.Gender, u.ClassID, u.Address, u.Birthday)
+	birthday := u.Birthday
+	if birthday == "" {
+		birthday = defaultBirthday
+	}
+	rs, err := stmt.Exec(u.RegisterID, u.RealName, u.Gender, u.ClassID, u.Address, birthday, u.Mobile)
 	if err != nil {
 		logs.Warn("[mysqlAgent::InsertUser]failed", err)
 		return 0, err
@@ -474,15 +607,64 @@ func (ma *mysqlAgent) InsertStudent(u *StudentInfo) (int64, error) {
 	return id, nil
 }
 
+// InsertStudents insert students in one transaction, id is filled on success
+func (ma *mysqlAgent) InsertStudents(list []*StudentInfo) error {
+	tx, err := ma.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO `tbStudent`(`vRegistNumber`, `vName`, `eGender`,`iClassID`,`vAddress`,`dtBirthday`,`vMobile`) VALUES (?,?,?,?,?,?,?)")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	ids := make([]int64, len(list))
+	for k, u := range list {
+		birthday := u.Birthday
+		if birthday == "" {
+			birthday = defaultBirthday
+		}
+		rs, err := stmt.Exec(u.RegisterID, u.RealName, u.Gender, u.ClassID, u.Address, birthday, u.Mobile)
+		if err != nil {
+			logs.Warn("[InsertStudents] execute sql failed", "registerID", u.RegisterID, "err", err)
+			tx.Rollback()
+			return err
+		}
+
+		ids[k], err = rs.LastInsertId()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	for k, u := range list {
+		u.StudentID = ids[k]
+	}
+	return nil
+}
+
 // UpdateStudent update student info
 func (ma *mysqlAgent) UpdateStudent(u *StudentInfo) error {
-	stmtIns, err := ma.db.Prepare("UPDATE tbStudent SET vRegistNumber=?,vName=?,eGender=?,iClassID=?,vAddress=?,dtBirthday=? WHERE iUserID=?;")
+	stmtIns, err := ma.db.Prepare("UPDATE tbStudent SET vRegistNumber=?,vName=?,eGender=?,iClassID=?,vAddress=?,dtBirthday=?,vMobile=? WHERE iUserID=?;")
 	if err != nil {
 		return err
 	}
 	defer stmtIns.Close()
 
-	_, err = stmtIns.Exec(u.RegisterID, u.RealName, u.Gender, u.ClassID, u.Address, u.Birthday, u.StudentID)
+	birthday := u.Birthday
+	if birthday == "" {
+		birthday = defaultBirthday
+	}
+	_, err = stmtIns.Exec(u.RegisterID, u.RealName, u.Gender, u.ClassID, u.Address, birthday, u.Mobile, u.StudentID)
 	if err != nil {
 		logs.Warn("execute sql failed", "err", err)
 		return err
@@ -508,14 +690,14 @@ func (ma *mysqlAgent) DeleteStudent(uid int64) error {
 
 // UpdatePassword password
 func (ma *mysqlAgent) InsertPassword(l *LoginInfo) error {
-	stmtIns, err := ma.db.Prepare("INSERT INTO `tbPassword`(`iUserID`, `eType`, `vLoginName`, `vPassword`) VALUES (?,?,?,?);")
+	stmtIns, err := ma.db.Prepare("INSERT INTO `tbPassword`(`iUserID`, `eType`, `vLoginName`, `vPassword`, `iRole`, `eStatus`, `bMustChange`) VALUES (?,?,?,?,?,?,?);")
 	if err != nil {
 		logs.Warn("[InsertPassword] Prepare sql failed", "err", err)
 		return err
 	}
 	defer stmtIns.Close()
 
-	_, err = stmtIns.Exec(l.ID, l.UserType, l.LoginName, l.Password)
+	_, err = stmtIns.Exec(l.ID, l.UserType, l.LoginName, l.Password, l.Role, l.Status, l.MustChange)
 	if err != nil {
 		logs.Warn("[InsertPassword] execute sql failed", "err", err)
 		return err
@@ -523,15 +705,15 @@ func (ma *mysqlAgent) InsertPassword(l *LoginInfo) error {
 	return nil
 }
 
-// UpdatePassword password
-func (ma *mysqlAgent) UpdatePassword(id int64, password string) error {
-	stmtIns, err := ma.db.Prepare("UPDATE tbPassword SET vPassword=? WHERE iUserID=?;")
+// UpdatePassword password, mustChange forces a change on next login
+func (ma *mysqlAgent) UpdatePassword(id int64, userType int, password string, mustChange bool) error {
+	stmtIns, err := ma.db.Prepare("UPDATE tbPassword SET vPassword=?,bMustChange=? WHERE iUserID=? AND eType=?;")
 	if err != nil {
 		return err
 	}
 	defer stmtIns.Close()
 
-	_, err = stmtIns.Exec(password, id)
+	_, err = stmtIns.Exec(password, mustChange, id, userType)
 	if err != nil {
 		logs.Warn("execute sql failed", "err", err)
 		return err
@@ -539,8 +721,88 @@ func (ma *mysqlAgent) UpdatePassword(id int64, password string) error {
 	return nil
 }
 
+// UpdateRole set role of an account
+func (ma *mysqlAgent) UpdateRole(id int64, userType int, role int) error {
+	stmtIns, err := ma.db.Prepare("UPDATE tbPassword SET iRole=? WHERE iUserID=? AND eType=?;")
+	if err != nil {
+		return err
+	}
+	defer stmtIns.Close()
+
+	_, err = stmtIns.Exec(role, id, userType)
+	if err != nil {
+		logs.Warn("[UpdateRole] execute sql failed", "err", err)
+		return err
+	}
+	return nil
+}
+
+// UpdateAccountStatus enable or disable an account
+func (ma *mysqlAgent) UpdateAccountStatus(id int64, userType int, status int) error {
+	stmtIns, err := ma.db.Prepare("UPDATE tbPassword SET eStatus=? WHERE iUserID=? AND eType=?;")
+	if err != nil {
+		return err
+	}
+	defer stmtIns.Close()
+
+	_, err = stmtIns.Exec(status, id, userType)
+	if err != nil {
+		logs.Warn("[UpdateAccountStatus] execute sql failed", "err", err)
+		return err
+	}
+	return nil
+}
+
+// UpsertScores save scores in one transaction, primary key is student, term, exam and subject
+func (ma *mysqlAgent) UpsertScores(list StudentScoreList) error {
+	return ma.upsertScores("INSERT INTO `tbStudentScore` (`iStudentID`,`iTermID`,`eExam`,`iSubjectID`,`iScore`) VALUES (?,?,?,?,?) ON DUPLICATE KEY UPDATE `iScore`=VALUES(`iScore`);", list)
+}
+
+// upsertScores execute the upsert statement of the dialect for each score
+func (ma *mysqlAgent) upsertScores(query string, list StudentScoreList) error {
+	tx, err := ma.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range list {
+		for _, v := range r.Scores {
+			_, err = stmt.Exec(r.StudentID, r.TermID, r.Exam, v.SubjectID, v.Score)
+			if err != nil {
+				logs.Warn("[upsertScores] execute sql failed", "studentID", r.StudentID, "err", err)
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// UpdateLoginName change login name of the account
+func (ma *mysqlAgent) UpdateLoginName(id int64, userType int, loginName string) error {
+	stmtIns, err := ma.db.Prepare("UPDATE tbPassword SET vLoginName=? WHERE iUserID=? AND eType=?;")
+	if err != nil {
+		return err
+	}
+	defer stmtIns.Close()
+
+	_, err = stmtIns.Exec(loginName, id, userType)
+	if err != nil {
+		logs.Warn("[UpdateLoginName] execute sql failed", "err", err)
+		return err
+	}
+	return nil
+}
+
 func (ma *mysqlAgent) ResetAllPassword(password string) error {
-	stmtIns, err := ma.db.Prepare("UPDATE tbPassword SET vPassword=? WHERE eType=?;")
+	stmtIns, err := ma.db.Prepare("UPDATE tbPassword SET vPassword=?,bMustChange=1 WHERE eType=?;")
 	if err != nil {
 		return err
 	}
@@ -641,13 +903,13 @@ func (ma *mysqlAgent) DeleteSubject(id int) error {
 // AddQuestionnaire add new questionnaire to current system
 func (ma *mysqlAgent) AddQuestionnaire(q *QuestionnaireInfo) error {
 
-	stmtIns, err := ma.db.Prepare("INSERT INTO tbQuestionnaire (`vTitle`,`dtStartTime`,`dtStopTime`,`eDraftStatus`,`vEditorName`) VALUES (?,?,?,?,?);")
+	stmtIns, err := ma.db.Prepare("INSERT INTO tbQuestionnaire (`vTitle`,`dtStartTime`,`dtStopTime`,`eDraftStatus`,`vEditorName`,`bAllowAmend`) VALUES (?,?,?,?,?,?);")
 	if err != nil {
 		return err
 	}
 	defer stmtIns.Close()
 
-	resp, err := stmtIns.Exec(base64.StdEncoding.EncodeToString([]byte(q.Title)), q.StartTime, q.StopTime, q.Status, q.Editor)
+	resp, err := stmtIns.Exec(base64.StdEncoding.EncodeToString([]byte(q.Title)), q.StartTime, q.StopTime, q.Status, q.Editor, boolToInt(q.AllowAmend))
 	if err != nil {
 		logs.Warn("[AddQuestionnaire] execute sql failed", "err", err)
 		return err
@@ -664,13 +926,13 @@ func (ma *mysqlAgent) AddQuestionnaire(q *QuestionnaireInfo) error {
 
 // UpdateQuestionnaire modify questionnaire info, not its
 func (ma *mysqlAgent) UpdateQuestionnaire(q *QuestionnaireInfo) error {
-	stmtIns, err := ma.db.Prepare("UPDATE tbQuestionnaire SET `vTitle`=?,`dtStartTime`=?,`dtStopTime`=?,`vEditorName`=? WHERE iQuestionnaireID=? AND `eDraftStatus`=1")
+	stmtIns, err := ma.db.Prepare("UPDATE tbQuestionnaire SET `vTitle`=?,`dtStartTime`=?,`dtStopTime`=?,`vEditorName`=?,`bAllowAmend`=? WHERE iQuestionnaireID=? AND `eDraftStatus`=1")
 	if err != nil {
 		return err
 	}
 	defer stmtIns.Close()
 
-	resp, err := stmtIns.Exec(base64.StdEncoding.EncodeToString([]byte(q.Title)), q.StartTime, q.StopTime, q.Editor, q.QuestionnaireID)
+	resp, err := stmtIns.Exec(base64.StdEncoding.EncodeToString([]byte(q.Title)), q.StartTime, q.StopTime, q.Editor, boolToInt(q.AllowAmend), q.QuestionnaireID)
 	if err != nil {
 		logs.Warn("[UpdateQuestionnaire] execute sql failed", "err", err)
 		return err
@@ -688,25 +950,30 @@ func (ma *mysqlAgent) UpdateQuestionnaire(q *QuestionnaireInfo) error {
 	return nil
 }
 
-func (ma *mysqlAgent) ExpireQuestionnaire(q *QuestionnaireInfo) error {
-	stmtIns, err := ma.db.Prepare("UPDATE tbQuestionnaire (`vTitle`,`dtStartTime`,`dtStopTime`,`eDraftStatus`) VALUES (?,?,?,?);")
+// UpdateQuestionnaireStatus change status of questionnaire, from is used to guard concurrent change
+func (ma *mysqlAgent) UpdateQuestionnaireStatus(id, from, to int) error {
+	stmtIns, err := ma.db.Prepare("UPDATE tbQuestionnaire SET `eDraftStatus`=? WHERE `iQuestionnaireID`=? AND `eDraftStatus`=?;")
 	if err != nil {
 		return err
 	}
 	defer stmtIns.Close()
 
-	resp, err := stmtIns.Exec(base.StatusDeleted, q.Title, q.StartTime, q.StopTime, q.Status)
+	resp, err := stmtIns.Exec(to, id, from)
 	if err != nil {
-		logs.Warn("[AddQuestionnaire] execute sql failed", "err", err)
+		logs.Warn("[UpdateQuestionnaireStatus] execute sql failed", "err", err)
 		return err
 	}
 
-	tmp, err := resp.LastInsertId()
+	count, err := resp.RowsAffected()
 	if err != nil {
-		logs.Warn("[AddQuestionnaire] insert tbQuestionnaire error", "err", err)
+		logs.Warn("[UpdateQuestionnaireStatus] unexpected update rows", "err", err)
 		return err
 	}
-	q.QuestionnaireID = int(tmp)
+
+	if count != 1 {
+		logs.Warn("[UpdateQuestionnaireStatus] data error", "id", id, "count", count)
+		return errors.New("data error")
+	}
 	return nil
 }
 
@@ -749,13 +1016,7 @@ func (ma *mysqlAgent) AddQuestion(questionnaireID int, info *QuestionInfo) (int,
 	}
 	defer stmtIns.Close()
 
-	resp, err := stmtIns.Exec(questionnaireID, base64.StdEncoding.EncodeToString([]byte(info.Question)), info.Index, info.Type, func() int {
-		if info.Required {
-			return 1
-		} else {
-			return 0
-		}
-	}(), encoded)
+	resp, err := stmtIns.Exec(questionnaireID, base64.StdEncoding.EncodeToString([]byte(info.Question)), info.Index, info.Type, boolToInt(info.Required), encoded)
 
 	if err != nil {
 		logs.Warn("[AddQuestion] execute sql failed", "err", err)
@@ -783,13 +1044,7 @@ func (ma *mysqlAgent) UpdateQuestion(info *QuestionInfo) (int, error) {
 	}
 	defer stmtIns.Close()
 
-	resp, err := stmtIns.Exec(base64.StdEncoding.EncodeToString([]byte(info.Question)), info.Index, info.Type, func() int {
-		if info.Required {
-			return 1
-		} else {
-			return 0
-		}
-	}(), encoded, info.QuestionID)
+	resp, err := stmtIns.Exec(base64.StdEncoding.EncodeToString([]byte(info.Question)), info.Index, info.Type, boolToInt(info.Required), encoded, info.QuestionID)
 
 	if err != nil {
 		logs.Warn("[UpdateQuestion] execute sql failed", "err", err)
@@ -830,3 +1085,74 @@ func (ma *mysqlAgent) DeleteQuestion(questionID int) error {
 	}
 	return nil
 }
+
+// InsertSubmission save answers of a student, one row per teacher
+func (ma *mysqlAgent) InsertSubmission(req QuestionnaireSubmit, source sourceMeta) error {
+	tx, err := ma.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmtIns, err := tx.Prepare("INSERT INTO tbSubmission (`iQuestionnaireID`,`iStudentID`,`iTeacherID`,`iGrade`,`iIndex`,`vContent`) VALUES (?,?,?,?,?,?)")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmtIns.Close()
+
+	for _, v := range req.TeacherAnswers {
+		buff, err := json.Marshal(v.Answers)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		_, err = stmtIns.Exec(req.QuestionnaireID, req.StudentID, v.TeacherID, source.Grade, source.Index, base64.StdEncoding.EncodeToString(buff))
+		if err != nil {
+			logs.Warn("[InsertSubmission] execute sql failed", "err", err)
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ReplaceSubmission archive previous answers of the student and save the new one,
+// only the last archive is kept
+func (ma *mysqlAgent) ReplaceSubmission(req QuestionnaireSubmit, source sourceMeta) error {
+	tx, err := ma.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM tbSubmission WHERE iQuestionnaireID=? AND iStudentID=? AND eStatus=?;", req.QuestionnaireID, req.StudentID, base.StatusArchived)
+	if err != nil {
+		logs.Warn("[ReplaceSubmission] execute sql failed", "err", err)
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE tbSubmission SET eStatus=? WHERE iQuestionnaireID=? AND iStudentID=? AND eStatus=?;", base.StatusArchived, req.QuestionnaireID, req.StudentID, base.StatusValid)
+	if err != nil {
+		logs.Warn("[ReplaceSubmission] execute sql failed", "err", err)
+		tx.Rollback()
+		return err
+	}
+
+	for _, v := range req.TeacherAnswers {
+		buff, err := json.Marshal(v.Answers)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		_, err = tx.Exec("INSERT INTO tbSubmission (`iQuestionnaireID`,`iStudentID`,`iTeacherID`,`iGrade`,`iIndex`,`vContent`) VALUES (?,?,?,?,?,?)",
+			req.QuestionnaireID, req.StudentID, v.TeacherID, source.Grade, source.Index, base64.StdEncoding.EncodeToString(buff))
+		if err != nil {
+			logs.Warn("[ReplaceSubmission] execute sql failed", "err", err)
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
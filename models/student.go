@@ -12,10 +12,24 @@ import (
 // Um user manager
 var Um userManager
 
+//go:generate mockgen -destination=./mock_student.go -source=student.go StudentStore
+type StudentStore interface {
+	InsertStudent(*StudentInfo) (int64, error)
+	InsertStudents([]*StudentInfo) error
+	UpdateStudent(*StudentInfo) error
+	DeleteStudent(int64) error
+}
+
 type userManager struct {
 	idMap   map[int64]*StudentInfo
 	uuidMap map[string]*StudentInfo
 	mutex   sync.Mutex
+	store   StudentStore
+}
+
+// SetStore set storage of student
+func (um *userManager) SetStore(s StudentStore) {
+	um.store = s
 }
 
 // Init: Init
@@ -34,21 +48,25 @@ func (um *userManager) Init(userMap map[int64]*StudentInfo) {
 // AddUser: AddUser
 func (um *userManager) AddUser(u *StudentInfo) (int64, error) {
 	var err error
-	if len(u.RealName) == 0 {
-		return 0, errors.New("invalid name")
+	err = u.Check()
+	if err != nil {
+		logs.Debug("[userManager::AddUser] invalid student", "err", err)
+		return 0, err
 	}
 
-	if u.Gender < eGenderMale || u.Gender > eGenderUnknown {
-		return 0, errors.New("invalid gender")
+	if _, ok := um.uuidMap[u.RegisterID]; ok {
+		return 0, ErrRegisterExist
 	}
 
-	u.StudentID, err = Ma.InsertStudent(u)
+	u.StudentID, err = um.store.InsertStudent(u)
 	if err != nil {
 		logs.Info("[AddUser]add user failed", err)
 		return 0, err
 	}
 
 	um.idMap[u.StudentID] = u
+	um.uuidMap[u.RegisterID] = u
+	Cm.moveStudent(u.StudentID, 0, u.ClassID)
 
 	return u.StudentID, nil
 }
@@ -56,22 +74,25 @@ func (um *userManager) AddUser(u *StudentInfo) (int64, error) {
 // DelUser: DelUser
 func (um *userManager) DelUser(uidList []int64) error {
 	for _, uid := range uidList {
-		_, ok := um.idMap[uid]
+		curr, ok := um.idMap[uid]
 		if !ok {
 			return errNotExist
 		}
 
-		err := Ma.DeleteStudent(uid)
+		err := um.store.DeleteStudent(uid)
 		if err != nil {
 			logs.Warn("[userManager::DelUser] failed", err)
 			return err
 		}
 		delete(um.idMap, uid)
+		delete(um.uuidMap, curr.RegisterID)
+		Cm.moveStudent(uid, curr.ClassID, 0)
 	}
 	return nil
 }
 
-// ModUser: ModUser
+// ModUser replace info of the student, cache is updated after the change is
+// committed. the login name follows the register number
 func (um *userManager) ModUser(u *StudentInfo) error {
 	if u.StudentID == 0 {
 		return errors.New("invalid user id")
@@ -82,9 +103,62 @@ func (um *userManager) ModUser(u *StudentInfo) error {
 		return errNotExist
 	}
 
-	if u.RegisterID != "" && curr.RegisterID != u.RegisterID {
-		curr.RegisterID = u.RegisterID
+	tmp := *u
+	err := tmp.Check()
+	if err != nil {
+		logs.Debug("[userManager::ModUser] invalid student", "err", err)
+		return err
+	}
+
+	if tmp == *curr {
+		logs.Debug("[userManager::ModUser] need do nothing")
+		return nil
+	}
+
+	if v, ok := um.uuidMap[tmp.RegisterID]; ok && v.StudentID != tmp.StudentID {
+		return ErrRegisterExist
 	}
+
+	renamed := curr.RegisterID != tmp.RegisterID
+	if renamed {
+		err = Ac.checkRename(tmp.StudentID, tmp.RegisterID)
+		if err != nil {
+			logs.Debug("[userManager::ModUser] login name taken", "registerID", tmp.RegisterID)
+			return err
+		}
+	}
+
+	err = um.store.UpdateStudent(&tmp)
+	if err != nil {
+		logs.Warn("[userManager::ModUser] UpdateStudent failed", "err", err)
+		return err
+	}
+
+	// student is restored if the account can't follow, so that both keep the
+	// same register number
+	if renamed {
+		err = Ac.RenameStudent(tmp.StudentID, tmp.RegisterID)
+		if err != nil {
+			logs.Warn("[userManager::ModUser] RenameStudent failed", "studentID", tmp.StudentID, "err", err)
+			if e := um.store.UpdateStudent(curr); e != nil {
+				logs.Error("[userManager::ModUser] restore student failed", "studentID", tmp.StudentID, "err", e)
+			}
+			return err
+		}
+	}
+
+	old := *curr
+	*curr = tmp
+	if renamed {
+		delete(um.uuidMap, old.RegisterID)
+		um.uuidMap[tmp.RegisterID] = curr
+	}
+
+	if old.ClassID != tmp.ClassID {
+		Cm.moveStudent(tmp.StudentID, old.ClassID, tmp.ClassID)
+	}
+
+	logs.Info("[userManager::ModUser] student updated", "studentID", tmp.StudentID)
 	return nil
 }
 
@@ -158,6 +232,26 @@ func (um *userManager) getStudentList(grade int) ([]int64, error) {
 	return ret, nil
 }
 
+// GetClassStudents students of the class, sorted
+func (um *userManager) GetClassStudents(classID int) studentList {
+	ret := studentList{}
+	for _, v := range um.idMap {
+		if v.ClassID == classID {
+			ret = append(ret, v)
+		}
+	}
+	sort.Sort(ret)
+	return ret
+}
+
+func (um *userManager) getClassStudentList(classID int) []int64 {
+	ret := []int64{}
+	for _, v := range um.GetClassStudents(classID) {
+		ret = append(ret, v.StudentID)
+	}
+	return ret
+}
+
 // IsExist: IsExist
 func (um *userManager) IsExist(studentID int64) bool {
 	_, ok := um.idMap[studentID]
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+
+	"github.com/arong/dean/base"
+)
+
+func TestUserManager_AddUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := NewMockStudentStore(mockCtrl)
+	um := userManager{store: mockStore}
+	um.Init(nil)
+
+	_, err := um.AddUser(&StudentInfo{})
+	if err == nil {
+		t.Fatal("add student without name success")
+	}
+
+	mockStore.EXPECT().InsertStudent(gomock.Any()).Return(int64(1), nil)
+	id, err := um.AddUser(&StudentInfo{profile: profile{RealName: "赵一", Gender: eGenderMale}, RegisterID: "2019001"})
+	if err != nil || id != 1 || !um.IsExist(1) {
+		t.Fatal("add student failed", err)
+	}
+
+	mockStore.EXPECT().InsertStudent(gomock.Any()).Return(int64(0), errors.New("sank your ship"))
+	_, err = um.AddUser(&StudentInfo{profile: profile{RealName: "钱二", Gender: eGenderMale}, RegisterID: "2019002"})
+	if err == nil {
+		t.Fatal("logic error")
+	}
+}
+
+func TestUserManager_DelUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := NewMockStudentStore(mockCtrl)
+	um := userManager{store: mockStore}
+	um.Init(map[int64]*StudentInfo{
+		1: {StudentID: 1, RegisterID: "2019001"},
+		2: {StudentID: 2, RegisterID: "2019002"},
+	})
+
+	mockStore.EXPECT().DeleteStudent(int64(1)).Return(nil)
+	err := um.DelUser([]int64{1})
+	if err != nil || um.IsExist(1) {
+		t.Fatal("delete student failed", err)
+	}
+
+	err = um.DelUser([]int64{1})
+	if err == nil {
+		t.Fatal("delete non-existing success")
+	}
+
+	mockStore.EXPECT().DeleteStudent(int64(2)).Return(errors.New("sank your ship"))
+	err = um.DelUser([]int64{2})
+	if err == nil || !um.IsExist(2) {
+		t.Fatal("logic error")
+	}
+}
+
+func TestUserManager_ModUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	Cm = classManager{idMap: map[int]*Class{1: {ID: 1}, 2: {ID: 2}}}
+	defer func() { Cm = classManager{} }()
+
+	mockPassword := NewMockPasswordStore(mockCtrl)
+	key := LoginKey{UserType: base.AccountTypeStudent, LoginName: "2019001"}
+	Ac = accessControl{db: mockPassword, tokenMap: make(map[string]*LoginInfo), loginMap: map[LoginKey]*LoginInfo{
+		key: {UserType: base.AccountTypeStudent, ID: 1, LoginName: "2019001"},
+	}}
+	defer func() { Ac = accessControl{} }()
+
+	mockStore := NewMockStudentStore(mockCtrl)
+	um := userManager{store: mockStore}
+	students := map[int64]*StudentInfo{
+		1: {StudentID: 1, RegisterID: "2019001", ClassID: 1, profile: profile{RealName: "赵一", Gender: eGenderMale}},
+		2: {StudentID: 2, RegisterID: "2019002", ClassID: 1, profile: profile{RealName: "钱二", Gender: eGenderFemale}},
+	}
+	um.Init(students)
+	Cm.SetStudents(students)
+
+	if err := um.ModUser(&StudentInfo{StudentID: 3, RegisterID: "2019003", profile: profile{RealName: "孙三", Gender: eGenderMale}}); err != errNotExist {
+		t.Fatal("modified non-existing student", err)
+	}
+
+	req := *students[1]
+	req.RealName = ""
+	if err := um.ModUser(&req); err != ErrName {
+		t.Fatal("invalid name accepted", err)
+	}
+
+	req = *students[1]
+	req.ClassID = 3
+	if err := um.ModUser(&req); err != ErrClassNotExist {
+		t.Fatal("invalid class accepted", err)
+	}
+
+	req = *students[1]
+	req.RegisterID = "2019002"
+	if err := um.ModUser(&req); err != ErrRegisterExist {
+		t.Fatal("duplicate register number accepted", err)
+	}
+
+	// failed update keeps the cache
+	req = *students[1]
+	req.RegisterID = "2019011"
+	req.ClassID = 2
+	req.Birthday = "2008-09-01"
+	mockStore.EXPECT().UpdateStudent(gomock.Any()).Return(errors.New("sank your ship"))
+	if err := um.ModUser(&req); err == nil || students[1].RegisterID != "2019001" || students[1].ClassID != 1 {
+		t.Fatal("logic error", err)
+	}
+
+	// login name held by another account, nothing is updated
+	taken := LoginKey{UserType: base.AccountTypeStudent, LoginName: "2019021"}
+	Ac.loginMap[taken] = &LoginInfo{UserType: base.AccountTypeStudent, ID: 9, LoginName: "2019021"}
+	req.RegisterID = "2019021"
+	if err := um.ModUser(&req); err != ErrLoginNameExist || students[1].RegisterID != "2019001" {
+		t.Fatal("login name collision accepted", err)
+	}
+	delete(Ac.loginMap, taken)
+
+	// account failed to follow, student is restored
+	req.RegisterID = "2019011"
+	mockStore.EXPECT().UpdateStudent(gomock.Any()).Return(nil)
+	mockPassword.EXPECT().UpdateLoginName(int64(1), base.AccountTypeStudent, "2019011").Return(errors.New("sank your ship"))
+	mockStore.EXPECT().UpdateStudent(students[1]).Return(nil)
+	if err := um.ModUser(&req); err == nil || students[1].RegisterID != "2019001" || Ac.loginMap[key] == nil {
+		t.Fatal("logic error", err)
+	}
+
+	mockStore.EXPECT().UpdateStudent(gomock.Any()).Return(nil)
+	mockPassword.EXPECT().UpdateLoginName(int64(1), base.AccountTypeStudent, "2019011").Return(nil)
+	if err := um.ModUser(&req); err != nil {
+		t.Fatal("update failed", err)
+	}
+	if s, err := um.GetStudentByRegisterNumber("2019011"); err != nil || s.StudentID != 1 || s.Age == 0 {
+		t.Fatal("register number not updated", s, err)
+	}
+	if _, err := um.GetStudentByRegisterNumber("2019001"); err == nil {
+		t.Fatal("old register number still found")
+	}
+	if _, ok := Ac.loginMap[key]; ok {
+		t.Fatal("login name not updated")
+	}
+	c1, _ := Cm.GetInfo(1)
+	c2, _ := Cm.GetInfo(2)
+	if len(c1.StudentList) != 1 || c1.StudentList[0] != 2 || len(c2.StudentList) != 1 || c2.StudentList[0] != 1 {
+		t.Fatal("class student list not synced", c1.StudentList, c2.StudentList)
+	}
+
+	// same info, nothing to do
+	if err := um.ModUser(&req); err != nil {
+		t.Fatal(err)
+	}
+
+	mockStore.EXPECT().DeleteStudent(int64(1)).Return(nil)
+	if err := um.DelUser([]int64{1}); err != nil {
+		t.Fatal(err)
+	}
+	if c2, _ = Cm.GetInfo(2); len(c2.StudentList) != 0 {
+		t.Fatal("deleted student still in class", c2.StudentList)
+	}
+}
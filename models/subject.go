@@ -66,6 +66,11 @@ func (sm *SubjectManager) get(id int) (SubjectInfo, error) {
 	}
 }
 
+// SetStore set storage of subject
+func (sm *SubjectManager) SetStore(s SubjectStore) {
+	sm.store = s
+}
+
 func (sm *SubjectManager) Init(list SubjectList) {
 	sm.idMap = make(map[int]int)
 	sm.keyMap = make(map[string]int)
@@ -247,6 +252,17 @@ func (sm *SubjectManager) IsExist(id int) bool {
 	return ok
 }
 
+func (sm *SubjectManager) getSubjectKey(id int) string {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	s, err := sm.get(id)
+	if err != nil {
+		return ""
+	}
+	return s.Key
+}
+
 func (sm *SubjectManager) getSubjectName(id int) string {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
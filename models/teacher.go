@@ -23,13 +23,51 @@ const (
 // Tm a global handler
 var Tm TeacherManager
 
+//go:generate mockgen -destination=./mock_teacher.go -source=teacher.go TeacherStore
+type TeacherStore interface {
+	InsertTeacher(Teacher) (int64, error)
+	UpdateTeacher(Teacher) error
+	DeleteTeacher([]int64) error
+}
+
 // Init init da config
 func Init(conf *DBConfig) {
 	// allocate memory
-	Ma.Init(conf)
+	var store dataStore
+	switch conf.Driver {
+	case DriverSQLite:
+		Sa.Init(conf)
+		store = &Sa
+	case DriverMySQL, "":
+		Ma.Init(conf)
+		store = &Ma
+	default:
+		logs.Error("unknown storage driver", "driver", conf.Driver)
+		os.Exit(-1)
+	}
+
+	// refuse to run against an out-of-date schema
+	migrator, err := NewMigrator(conf)
+	if err == nil {
+		err = migrator.Check()
+		migrator.Close()
+	}
+	if err != nil {
+		logs.Error("schema check failed, run `dean migrate` first", "err", err)
+		os.Exit(-1)
+	}
+
+	// bind storage
+	Sm.SetStore(store)
+	Tm.SetStore(store)
+	Cm.SetStore(store)
+	Um.SetStore(store)
+	QuestionnaireManager.SetStore(store)
+	SSM.SetStore(store)
+	Ac.SetPasswordStore(store)
 
 	// data warm up
-	err := Ma.LoadAllData()
+	err = store.LoadAllData()
 	if err != nil {
 		logs.Error("init failed", "err", err)
 		os.Exit(-1)
@@ -53,6 +91,10 @@ type TeacherManager struct {
 	deletedCount int32
 	// signal channel
 	ch chan bool
+	// start cleaner only once, Init could be called again
+	cleaner sync.Once
+	// db persist teacher info
+	db TeacherStore
 }
 
 func (tm *TeacherManager) save(t Teacher) {
@@ -140,6 +182,11 @@ var (
 	errNameExist    = errors.New("name exist")
 )
 
+// SetStore set storage of teacher
+func (tm *TeacherManager) SetStore(s TeacherStore) {
+	tm.db = s
+}
+
 // Init: Init
 func (tm *TeacherManager) Init(data TeacherList) {
 	if tm == nil || data == nil {
@@ -161,8 +208,10 @@ func (tm *TeacherManager) Init(data TeacherList) {
 	}
 
 	tm.mutex.Unlock()
-	tm.ch = make(chan bool)
-	go tm.clean()
+	tm.cleaner.Do(func() {
+		tm.ch = make(chan bool)
+		go tm.clean()
+	})
 }
 
 // AddTeacher: AddTeacher
@@ -181,7 +230,7 @@ func (tm *TeacherManager) AddTeacher(t *Teacher) (int64, error) {
 		return 0, err
 	}
 
-	t.TeacherID, err = Ma.InsertTeacher(*t)
+	t.TeacherID, err = tm.db.InsertTeacher(*t)
 	if err != nil {
 		logs.Warn("[TeacherManager::AddTeacher] database error")
 		return 0, err
@@ -248,7 +297,7 @@ func (tm *TeacherManager) ModTeacher(t *Teacher) error {
 		curr.Address = t.Address
 	}
 
-	err = Ma.UpdateTeacher(curr)
+	err = tm.db.UpdateTeacher(curr)
 	if err != nil {
 		logs.Info("[TeacherManager::ModTeacher] UpdateTeacher failed", "err", err)
 		return err
@@ -286,7 +335,7 @@ func (tm *TeacherManager) DelTeacher(idList []int64) ([]int64, error) {
 		tm.delete(k)
 	}
 	// delete from database
-	err := Ma.DeleteTeacher(idList)
+	err := tm.db.DeleteTeacher(idList)
 	if err != nil {
 		logs.Warn("[TeacherManager::DelTeacher] database error")
 	}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+)
+
+var teachers = TeacherList{
+	{TeacherMeta: TeacherMeta{TeacherID: 1, Name: "赵一", Gender: eGenderMale}},
+	{TeacherMeta: TeacherMeta{TeacherID: 2, Name: "钱二", Gender: eGenderFemale}},
+	{TeacherMeta: TeacherMeta{TeacherID: 3, Name: "孙三", Gender: eGenderUnknown}},
+}
+
+func newTeacherManager(store TeacherStore) *TeacherManager {
+	tm := &TeacherManager{}
+	tm.SetStore(store)
+	data := TeacherList{}
+	data = append(data, teachers...)
+	tm.Init(data)
+	return tm
+}
+
+func TestTeacherManager_AddTeacher(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := NewMockTeacherStore(mockCtrl)
+	tm := newTeacherManager(mockStore)
+
+	for _, v := range teachers {
+		tmp := v
+		tmp.TeacherID = 0
+		_, err := tm.AddTeacher(&tmp)
+		if err == nil {
+			t.Fatal("add duplicated name success")
+		}
+	}
+
+	mockStore.EXPECT().InsertTeacher(gomock.Any()).Return(int64(4), nil)
+	id, err := tm.AddTeacher(&Teacher{TeacherMeta: TeacherMeta{Name: "李四", Gender: eGenderMale}})
+	if err != nil || id != 4 || !tm.IsExist(4) {
+		t.Fatal("add teacher failed", err)
+	}
+
+	mockStore.EXPECT().InsertTeacher(gomock.Any()).Return(int64(0), errors.New("sank your ship"))
+	_, err = tm.AddTeacher(&Teacher{TeacherMeta: TeacherMeta{Name: "周五", Gender: eGenderMale}})
+	if err == nil || tm.IsExist(5) {
+		t.Fatal("logic error")
+	}
+}
+
+func TestTeacherManager_ModTeacher(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := NewMockTeacherStore(mockCtrl)
+	tm := newTeacherManager(mockStore)
+
+	// nothing changed
+	tmp := teachers[0]
+	err := tm.ModTeacher(&tmp)
+	if err != nil {
+		t.Fatal("modify without change failed", err)
+	}
+
+	tmp.Name = "吴六"
+	err = tm.ModTeacher(&tmp)
+	if err == nil {
+		t.Fatal("modify name success")
+	}
+
+	tmp = teachers[0]
+	tmp.Mobile = "13800000000"
+	mockStore.EXPECT().UpdateTeacher(gomock.Any()).Return(nil)
+	err = tm.ModTeacher(&tmp)
+	if err != nil {
+		t.Fatal("modify mobile failed", err)
+	}
+
+	info, _ := tm.GetTeacherInfo(tmp.TeacherID)
+	if info.Mobile != tmp.Mobile {
+		t.Fatal("cache not updated")
+	}
+
+	tmp.Mobile = "13900000000"
+	mockStore.EXPECT().UpdateTeacher(gomock.Any()).Return(errors.New("sank your ship"))
+	err = tm.ModTeacher(&tmp)
+	if err == nil {
+		t.Fatal("logic error")
+	}
+}
+
+func TestTeacherManager_DelTeacher(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStore := NewMockTeacherStore(mockCtrl)
+	tm := newTeacherManager(mockStore)
+
+	mockStore.EXPECT().DeleteTeacher(gomock.Any()).Return(nil)
+	failed, err := tm.DelTeacher([]int64{1, 100})
+	if err != nil || len(failed) != 1 || failed[0] != 100 {
+		t.Fatal("delete teacher failed", failed, err)
+	}
+
+	if tm.IsExist(1) || !tm.IsExist(2) {
+		t.Fatal("delete logic error")
+	}
+}
@@ -47,6 +47,11 @@ func init() {
 					),
 				),
 			),
+			beego.NSNamespace("/master",
+				beego.NSInclude(
+					&controllers.MasterController{},
+				),
+			),
 			beego.NSNamespace("/student",
 				beego.NSInclude(
 					&controllers.StudentController{},
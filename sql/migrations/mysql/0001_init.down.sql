@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS `tbVote`;
+DROP TABLE IF EXISTS `tbSubmission`;
+DROP TABLE IF EXISTS `tbOption`;
+DROP TABLE IF EXISTS `tbQuestion`;
+DROP TABLE IF EXISTS `tbQuestionnaire`;
+DROP TABLE IF EXISTS `tbTerm`;
+DROP TABLE IF EXISTS `tbTeacherScore`;
+DROP TABLE IF EXISTS `tbStudentScore`;
+DROP TABLE IF EXISTS `tbPassword`;
+DROP TABLE IF EXISTS `tbStudent`;
+DROP TABLE IF EXISTS `tbClassTeacherRelation`;
+DROP TABLE IF EXISTS `tbClass`;
+DROP TABLE IF EXISTS `tbTeacher`;
+DROP TABLE IF EXISTS `tbSubject`;
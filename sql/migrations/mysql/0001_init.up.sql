@@ -0,0 +1,157 @@
+CREATE TABLE IF NOT EXISTS `tbSubject`  (
+  `iSubjectID`    int(20) UNSIGNED    NOT NULL AUTO_INCREMENT                                           COMMENT '主键',
+  `vSubjectKey`   varchar(32)         NOT NULL DEFAULT ''                                               COMMENT '课程key',
+  `vSubjectName`  varchar(32)         NOT NULL DEFAULT ''                                               COMMENT '课程名称',
+  `eStatus`       tinyint(4)          NOT NULL DEFAULT 1                                                COMMENT '逻辑状态',
+  `dtCreateTime`  datetime(0)         NOT NULL DEFAULT CURRENT_TIMESTAMP                                COMMENT '创建时间',
+  `dtModifyTime`  datetime(0)         NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP(0) COMMENT '修改时间',
+  PRIMARY KEY (`iSubjectID`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8;
+
+CREATE TABLE IF NOT EXISTS `tbTeacher` (
+  `iTeacherID`   int(11) unsigned   NOT NULL AUTO_INCREMENT                                           COMMENT '主键',
+  `iSubjectID`   int(11) unsigned   NOT NULL DEFAULT 0                                                COMMENT '主授课程',
+  `eGender`      enum('1','2','3')  NOT NULL DEFAULT '3'                                              COMMENT '性别, 1: 男, 2: 女, 3: 未知',
+  `vName`        varchar(32)        NOT NULL DEFAULT ''                                               COMMENT '姓名',
+  `vMobile`      varchar(16)        NOT NULL DEFAULT ''                                               COMMENT '手机号',
+  `vAddress`     varchar(128)       NOT NULL DEFAULT ''                                               COMMENT '家庭地址',
+  `eStatus`      tinyint(1)         NOT NULL DEFAULT '1'                                              COMMENT '逻辑状态',
+  `dtBirthday`   date               NOT NULL DEFAULT '2008-01-01 00:00:00'                            COMMENT '生日',
+  `dtCreateTime` datetime           NOT NULL DEFAULT CURRENT_TIMESTAMP                                COMMENT '创建时间',
+  `dtModifyTime` datetime           NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP(0) COMMENT '修改时间',
+  PRIMARY KEY (`iTeacherID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE IF NOT EXISTS `tbClass` (
+  `iClassID`     int(10)     unsigned NOT NULL AUTO_INCREMENT                                           COMMENT '班级表主键',
+  `iGrade`       tinyint(1)  unsigned NOT NULL DEFAULT '0'                                              COMMENT '年级编号',
+  `iIndex`       tinyint(2)  unsigned NOT NULL DEFAULT '0'                                              COMMENT '班级编号',
+  `vName`        varchar(16)          NOT NULL DEFAULT ''                                               COMMENT '班级名称',
+  `iMasterID`    int(10)              NOT NULL DEFAULT '0'                                              COMMENT '班主任老师ID',
+  `iStartYear`   int(10)              NOT NULL DEFAULT '0'                                              COMMENT '开学年份',
+  `eTerm`        tinyint(1)           NOT NULL DEFAULT '0'                                              COMMENT '学期',
+  `eStatus`      tinyint(1)           NOT NULL DEFAULT '1'                                              COMMENT '逻辑状态',
+  `dtCreateTime` datetime             NOT NULL DEFAULT CURRENT_TIMESTAMP                                COMMENT '创建时间',
+  `dtModifyTime` datetime             NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP(0) COMMENT '修改时间',
+  PRIMARY KEY (`iClassID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE IF NOT EXISTS `tbClassTeacherRelation` (
+  `iClassTeacherRelationID` int(11) unsigned NOT NULL AUTO_INCREMENT                                           COMMENT '主键',
+  `iClassID`                int(11)          NOT NULL DEFAULT '0'                                              COMMENT '班级表主键',
+  `iSubjectID`              int(10)          NOT NULL DEFAULT '0'                                              COMMENT '科目',
+  `iTeacherID`              int(11)          NOT NULL DEFAULT '0'                                              COMMENT '教师表主键',
+  `eStatus`                 tinyint(1)       NOT NULL DEFAULT '1'                                              COMMENT '逻辑状态',
+  `dtCreateTime`            datetime         NOT NULL DEFAULT CURRENT_TIMESTAMP                                COMMENT '创建时间',
+  `dtModifyTime`            datetime         NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP(0) COMMENT '最后修改时间',
+  PRIMARY KEY (`iClassTeacherRelationID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE IF NOT EXISTS `tbStudent`  (
+  `iUserID`       bigint(20) UNSIGNED NOT NULL AUTO_INCREMENT                                           COMMENT '主键',
+  `iClassID`      tinyint(1) UNSIGNED NOT NULL DEFAULT '0'                                              COMMENT '年级编号',
+  `vRegistNumber` varchar(16)         NOT NULL DEFAULT ''                                               COMMENT '学号',
+  `vName`         varchar(32)         NOT NULL DEFAULT ''                                               COMMENT '学生姓名',
+  `vAddress`      varchar(255)        NOT NULL DEFAULT ''                                               COMMENT '家庭住址',
+  `eGender`       enum('1','2','3')   NOT NULL DEFAULT '3'                                              COMMENT '性别： 1男 2女 3未知',
+  `dtBirthday`    date                NOT NULL                                                          COMMENT '生日',
+  `eStatus`       tinyint(4)          NOT NULL DEFAULT 1                                                COMMENT '逻辑状态',
+  `dtCreateTime`  datetime(0)         NOT NULL DEFAULT CURRENT_TIMESTAMP                                COMMENT '创建时间',
+  `dtModifyTime`  datetime(0)         NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP(0) COMMENT '修改时间',
+  PRIMARY KEY (`iUserID`) USING BTREE
+) ENGINE = InnoDB  CHARACTER SET = utf8;
+
+CREATE TABLE IF NOT EXISTS `tbPassword` (
+  `iPasswordID`  bigint(20) UNSIGNED NOT NULL AUTO_INCREMENT                                        COMMENT '主键',
+  `iUserID`      bigint(20)          NOT NULL DEFAULT '0'                                           COMMENT 'tbUser表主键',
+  `eType`        enum('1','2')       NOT NULL DEFAULT '1'                                           COMMENT 'ID类型',
+  `vLoginName`   varchar(32)         NOT NULL DEFAULT ''                                            COMMENT '登录名',
+  `vPassword`    varchar(64)         NOT NULL DEFAULT ''                                            COMMENT '密码',
+  `dtCreateTime` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP                             COMMENT '创建时间',
+  `dtModifyTime` datetime            NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '修改时间',
+  PRIMARY KEY (`iPasswordID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE IF NOT EXISTS `tbStudentScore` (
+  -- `iStudentScoreID` INT(11) UNSIGNED NOT NULL AUTO_INCREMENT                                           COMMENT '主键',
+  `iStudentID`      BIGINT(20)       NOT NULL DEFAULT '0'                                              COMMENT '学生ID',
+  `iTermID`         INT(10)          NOT NULL DEFAULT '0'                                              COMMENT '学期ID',
+  `eExam`           TINYINT(1)       NOT NULL DEFAULT '0'                                              COMMENT '考试编号',
+  `iSubjectID`      INT(10)          NOT NULL DEFAULT '0'                                              COMMENT '课程ID',
+  `iScore`          SMALLINT(5)      NOT NULL DEFAULT '0'                                              COMMENT '分数',
+  `dtCreateTime`    DATETIME         NOT NULL DEFAULT CURRENT_TIMESTAMP                                COMMENT '创建时间',
+  `dtModifyTime`    DATETIME         NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP(0) COMMENT '修改时间',
+  PRIMARY KEY (`iStudentID`,`iTermID`,`eExam`,`iSubjectID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE IF NOT EXISTS `tbTeacherScore`  (
+  `iTeacherScoreID` int(11) unsigned NOT NULL AUTO_INCREMENT                                           COMMENT '主键',
+  `iTeacherID`      int(11)          NOT NULL DEFAULT '0'                                              COMMENT '教师表主键',
+  `iScore`          int(11)          NOT NULL DEFAULT '0'                                              COMMENT '分数',
+  `eStatus`         tinyint(1)       NOT NULL DEFAULT '1'                                              COMMENT '逻辑状态',
+  `dtCreateTime`    datetime(0)      NOT NULL DEFAULT CURRENT_TIMESTAMP                                COMMENT '创建时间',
+  `dtModifyTime`    datetime(0)      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP(0) COMMENT '修改时间',
+  PRIMARY KEY (`iTeacherScoreID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE IF NOT EXISTS `tbTerm` (
+  `iTermID`     int(10) unsigned    NOT NULL AUTO_INCREMENT       COMMENT '主键',
+  `iSchoolYear` int(10) unsigned    NOT NULL DEFAULT '0'          COMMENT '学年',
+  `eTerm`       tinyint(1) unsigned NOT NULL DEFAULT '0'          COMMENT '学期',
+  `dtBegin`     date                NOT NULL DEFAULT '0000-00-00' COMMENT '学期开始日期',
+  `dtEnd`       date                NOT NULL DEFAULT '0000-00-00' COMMENT '学期结束日期',
+  PRIMARY KEY (`iTermID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE IF NOT EXISTS `tbQuestionnaire` (
+   `iQuestionnaireID` int(10) NOT NULL AUTO_INCREMENT COMMENT '问卷id',
+   `vTitle` varchar(64) NOT NULL DEFAULT '' COMMENT '问卷标题',
+   `eDraftStatus` tinyint(1) NOT NULL DEFAULT '0' COMMENT '文稿状态, 1: 草稿, 2: 已发布, 3: 已撤回, 4: 已过期, 5: 待发布',
+   `dtStartTime` datetime NOT NULL DEFAULT '0000-00-00 00:00:00' COMMENT '开发日期',
+   `dtStopTime` datetime NOT NULL DEFAULT '0000-00-00 00:00:00' COMMENT '截至日期',
+   `vEditorName` varchar(32) NOT NULL DEFAULT '' COMMENT '编辑',
+   PRIMARY KEY (`iQuestionnaireID`)
+ ) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE IF NOT EXISTS tbQuestion (
+ `iQuestionID`      int(10)    NOT NULL AUTO_INCREMENT COMMENT '问卷id',
+ `iQuestionnaireID` int(10)    NOT NULL DEFAULT '0' COMMENT '问卷id',
+ `iIndex`           tinyint(2) NOT NULL DEFAULT '0' COMMENT '问题编号',
+ `eType`            tinyint(1) NOT NULL DEFAULT '0' COMMENT '问题类型, 1: 单选, 2: 多选, 3: 文本',
+ `bRequired`        tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否为必填项',
+ `vQuestion`        text       NOT NULL COMMENT '问卷标题, base64编码的文本',
+ `vContent`         text       NOT NULL COMMENT '题目内容, base64编码的json字符串',
+ PRIMARY KEY (`iQuestionID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE IF NOT EXISTS tbOption (
+ `iOptionID` int(10) not null AUTO_INCREMENT comment '问卷id',
+ `iQuestionID` int(10) not null default '0' comment '问卷id',
+ `vOption` varchar(64) not null default '' comment '问卷标题',
+ `iIndex` tinyint(2) not null default '0' comment '问题编号',
+ PRIMARY KEY (`iOptionID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE IF NOT EXISTS `tbSubmission` (
+  `iSubmissionID`    int(11) unsigned NOT NULL AUTO_INCREMENT                                           COMMENT '主键',
+  `iQuestionnaireID` int(10)          NOT NULL DEFAULT '0'                                              COMMENT '问卷id',
+  `iStudentID`       bigint(20)       NOT NULL DEFAULT '0'                                              COMMENT '学生ID',
+  `iTeacherID`       int(11)          NOT NULL DEFAULT '0'                                              COMMENT '教师ID',
+  `iGrade`           tinyint(1)       NOT NULL DEFAULT '0'                                              COMMENT '提交时所在年级',
+  `iIndex`           tinyint(2)       NOT NULL DEFAULT '0'                                              COMMENT '提交时所在班级',
+  `vContent`         text             NOT NULL                                                          COMMENT '答案内容, base64编码的json字符串',
+  `eStatus`          tinyint(1)       NOT NULL DEFAULT '1'                                              COMMENT '逻辑状态',
+  `dtCreateTime`     datetime         NOT NULL DEFAULT CURRENT_TIMESTAMP                                COMMENT '创建时间',
+  `dtModifyTime`     datetime         NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP(0) COMMENT '修改时间',
+  PRIMARY KEY (`iSubmissionID`),
+  KEY `idx_questionnaire_student` (`iQuestionnaireID`,`iStudentID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE IF NOT EXISTS `tbVote` (
+  `iVoteID`      int(11) unsigned NOT NULL AUTO_INCREMENT                                           COMMENT '主键',
+  `vVoteCode`    varchar(16)      NOT NULL DEFAULT ''                                               COMMENT '投票码',
+  `vVoteDetail`  varchar(256)     NOT NULL DEFAULT ''                                               COMMENT '投票详情',
+  `dtCreateTime` datetime         NOT NULL DEFAULT CURRENT_TIMESTAMP                                COMMENT '创建时间',
+  `dtModifyTime` datetime         NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP(0) COMMENT '修改时间',
+  PRIMARY KEY (`iVoteID`,`vVoteCode`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,5 @@
+ALTER TABLE `tbClassTeacherRelation` DROP KEY `idx_class`;
+
+ALTER TABLE `tbStudent`
+  DROP COLUMN `vMobile`,
+  MODIFY COLUMN `iClassID` tinyint(1) UNSIGNED NOT NULL DEFAULT '0' COMMENT '年级编号';
@@ -0,0 +1,5 @@
+ALTER TABLE `tbStudent`
+  ADD COLUMN `vMobile` varchar(16) NOT NULL DEFAULT '' COMMENT '手机号' AFTER `vName`,
+  MODIFY COLUMN `iClassID` int(10) UNSIGNED NOT NULL DEFAULT '0' COMMENT '班级表主键';
+
+ALTER TABLE `tbClassTeacherRelation` ADD KEY `idx_class` (`iClassID`);
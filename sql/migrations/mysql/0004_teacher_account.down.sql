@@ -0,0 +1 @@
+ALTER TABLE `tbPassword` DROP COLUMN `bMustChange`, DROP COLUMN `eStatus`;
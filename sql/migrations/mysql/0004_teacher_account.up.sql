@@ -0,0 +1,3 @@
+ALTER TABLE `tbPassword`
+  ADD COLUMN `eStatus` tinyint(3) NOT NULL DEFAULT '1' COMMENT '账号状态: 1 正常, 2 停用' AFTER `iRole`,
+  ADD COLUMN `bMustChange` tinyint(1) NOT NULL DEFAULT '0' COMMENT '下次登录须修改密码' AFTER `eStatus`;
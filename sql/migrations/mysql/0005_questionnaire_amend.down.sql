@@ -0,0 +1 @@
+ALTER TABLE `tbQuestionnaire` DROP COLUMN `bAllowAmend`;
@@ -0,0 +1 @@
+ALTER TABLE `tbQuestionnaire` ADD COLUMN `bAllowAmend` tinyint(1) NOT NULL DEFAULT '0' COMMENT '截至日期前是否允许修改答案' AFTER `vEditorName`;
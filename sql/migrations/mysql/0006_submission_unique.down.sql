@@ -0,0 +1 @@
+ALTER TABLE `tbSubmission` DROP INDEX `uk_submission`;
@@ -0,0 +1,4 @@
+DELETE s1 FROM `tbSubmission` s1 JOIN `tbSubmission` s2
+  ON s1.`iQuestionnaireID` = s2.`iQuestionnaireID` AND s1.`iStudentID` = s2.`iStudentID`
+  AND s1.`iTeacherID` = s2.`iTeacherID` AND s1.`eStatus` = s2.`eStatus` AND s1.`iSubmissionID` > s2.`iSubmissionID`;
+ALTER TABLE `tbSubmission` ADD UNIQUE KEY `uk_submission` (`iQuestionnaireID`,`iStudentID`,`iTeacherID`,`eStatus`);
@@ -0,0 +1,198 @@
+CREATE TABLE IF NOT EXISTS `tbSubject` (
+  `iSubjectID`   INTEGER PRIMARY KEY AUTOINCREMENT,                         -- 主键
+  `vSubjectKey`  TEXT    NOT NULL DEFAULT '',                               -- 课程key
+  `vSubjectName` TEXT    NOT NULL DEFAULT '',                               -- 课程名称
+  `eStatus`      INTEGER NOT NULL DEFAULT 1,                                -- 逻辑状态
+  `dtCreateTime` TEXT    NOT NULL DEFAULT (datetime('now', 'localtime')),   -- 创建时间
+  `dtModifyTime` TEXT    NOT NULL DEFAULT (datetime('now', 'localtime'))    -- 修改时间
+);
+
+CREATE TRIGGER IF NOT EXISTS `trgtbSubjectModifyTime` AFTER UPDATE ON `tbSubject` FOR EACH ROW
+BEGIN
+  UPDATE `tbSubject` SET `dtModifyTime` = datetime('now', 'localtime') WHERE `iSubjectID` = OLD.`iSubjectID`;
+END;
+
+CREATE TABLE IF NOT EXISTS `tbTeacher` (
+  `iTeacherID`   INTEGER PRIMARY KEY AUTOINCREMENT,                             -- 主键
+  `iSubjectID`   INTEGER NOT NULL DEFAULT 0,                                    -- 主授课程
+  `eGender`      INTEGER NOT NULL DEFAULT 3 CHECK (`eGender` IN (1, 2, 3)),     -- 性别, 1: 男, 2: 女, 3: 未知
+  `vName`        TEXT    NOT NULL DEFAULT '',                                   -- 姓名
+  `vMobile`      TEXT    NOT NULL DEFAULT '',                                   -- 手机号
+  `vAddress`     TEXT    NOT NULL DEFAULT '',                                   -- 家庭地址
+  `eStatus`      INTEGER NOT NULL DEFAULT 1,                                    -- 逻辑状态
+  `dtBirthday`   TEXT    NOT NULL DEFAULT '2008-01-01',                         -- 生日
+  `dtCreateTime` TEXT    NOT NULL DEFAULT (datetime('now', 'localtime')),       -- 创建时间
+  `dtModifyTime` TEXT    NOT NULL DEFAULT (datetime('now', 'localtime'))        -- 修改时间
+);
+
+CREATE TRIGGER IF NOT EXISTS `trgtbTeacherModifyTime` AFTER UPDATE ON `tbTeacher` FOR EACH ROW
+BEGIN
+  UPDATE `tbTeacher` SET `dtModifyTime` = datetime('now', 'localtime') WHERE `iTeacherID` = OLD.`iTeacherID`;
+END;
+
+CREATE TABLE IF NOT EXISTS `tbClass` (
+  `iClassID`     INTEGER PRIMARY KEY AUTOINCREMENT,                         -- 班级表主键
+  `iGrade`       INTEGER NOT NULL DEFAULT 0,                                -- 年级编号
+  `iIndex`       INTEGER NOT NULL DEFAULT 0,                                -- 班级编号
+  `vName`        TEXT    NOT NULL DEFAULT '',                               -- 班级名称
+  `iMasterID`    INTEGER NOT NULL DEFAULT 0,                                -- 班主任老师ID
+  `iStartYear`   INTEGER NOT NULL DEFAULT 0,                                -- 开学年份
+  `eTerm`        INTEGER NOT NULL DEFAULT 0,                                -- 学期
+  `eStatus`      INTEGER NOT NULL DEFAULT 1,                                -- 逻辑状态
+  `dtCreateTime` TEXT    NOT NULL DEFAULT (datetime('now', 'localtime')),   -- 创建时间
+  `dtModifyTime` TEXT    NOT NULL DEFAULT (datetime('now', 'localtime'))    -- 修改时间
+);
+
+CREATE TRIGGER IF NOT EXISTS `trgtbClassModifyTime` AFTER UPDATE ON `tbClass` FOR EACH ROW
+BEGIN
+  UPDATE `tbClass` SET `dtModifyTime` = datetime('now', 'localtime') WHERE `iClassID` = OLD.`iClassID`;
+END;
+
+CREATE TABLE IF NOT EXISTS `tbClassTeacherRelation` (
+  `iClassTeacherRelationID` INTEGER PRIMARY KEY AUTOINCREMENT,                         -- 主键
+  `iClassID`                INTEGER NOT NULL DEFAULT 0,                                -- 班级表主键
+  `iSubjectID`              INTEGER NOT NULL DEFAULT 0,                                -- 科目
+  `iTeacherID`              INTEGER NOT NULL DEFAULT 0,                                -- 教师表主键
+  `eStatus`                 INTEGER NOT NULL DEFAULT 1,                                -- 逻辑状态
+  `dtCreateTime`            TEXT    NOT NULL DEFAULT (datetime('now', 'localtime')),   -- 创建时间
+  `dtModifyTime`            TEXT    NOT NULL DEFAULT (datetime('now', 'localtime'))    -- 最后修改时间
+);
+
+CREATE TRIGGER IF NOT EXISTS `trgtbClassTeacherRelationModifyTime` AFTER UPDATE ON `tbClassTeacherRelation` FOR EACH ROW
+BEGIN
+  UPDATE `tbClassTeacherRelation` SET `dtModifyTime` = datetime('now', 'localtime') WHERE `iClassTeacherRelationID` = OLD.`iClassTeacherRelationID`;
+END;
+
+CREATE TABLE IF NOT EXISTS `tbStudent` (
+  `iUserID`       INTEGER PRIMARY KEY AUTOINCREMENT,                             -- 主键
+  `iClassID`      INTEGER NOT NULL DEFAULT 0,                                    -- 年级编号
+  `vRegistNumber` TEXT    NOT NULL DEFAULT '',                                   -- 学号
+  `vName`         TEXT    NOT NULL DEFAULT '',                                   -- 学生姓名
+  `vAddress`      TEXT    NOT NULL DEFAULT '',                                   -- 家庭住址
+  `eGender`       INTEGER NOT NULL DEFAULT 3 CHECK (`eGender` IN (1, 2, 3)),     -- 性别： 1男 2女 3未知
+  `dtBirthday`    TEXT    NOT NULL,                                              -- 生日
+  `eStatus`       INTEGER NOT NULL DEFAULT 1,                                    -- 逻辑状态
+  `dtCreateTime`  TEXT    NOT NULL DEFAULT (datetime('now', 'localtime')),       -- 创建时间
+  `dtModifyTime`  TEXT    NOT NULL DEFAULT (datetime('now', 'localtime'))        -- 修改时间
+);
+
+CREATE TRIGGER IF NOT EXISTS `trgtbStudentModifyTime` AFTER UPDATE ON `tbStudent` FOR EACH ROW
+BEGIN
+  UPDATE `tbStudent` SET `dtModifyTime` = datetime('now', 'localtime') WHERE `iUserID` = OLD.`iUserID`;
+END;
+
+CREATE TABLE IF NOT EXISTS `tbPassword` (
+  `iPasswordID`  INTEGER PRIMARY KEY AUTOINCREMENT,                           -- 主键
+  `iUserID`      INTEGER NOT NULL DEFAULT 0,                                  -- tbUser表主键
+  `eType`        INTEGER NOT NULL DEFAULT 1 CHECK (`eType` IN (1, 2)),        -- ID类型
+  `vLoginName`   TEXT    NOT NULL DEFAULT '',                                 -- 登录名
+  `vPassword`    TEXT    NOT NULL DEFAULT '',                                 -- 密码
+  `dtCreateTime` TEXT    NOT NULL DEFAULT (datetime('now', 'localtime')),     -- 创建时间
+  `dtModifyTime` TEXT    NOT NULL DEFAULT (datetime('now', 'localtime'))      -- 修改时间
+);
+
+CREATE TRIGGER IF NOT EXISTS `trgtbPasswordModifyTime` AFTER UPDATE ON `tbPassword` FOR EACH ROW
+BEGIN
+  UPDATE `tbPassword` SET `dtModifyTime` = datetime('now', 'localtime') WHERE `iPasswordID` = OLD.`iPasswordID`;
+END;
+
+CREATE TABLE IF NOT EXISTS `tbStudentScore` (
+  `iStudentID`   INTEGER NOT NULL DEFAULT 0,                                -- 学生ID
+  `iTermID`      INTEGER NOT NULL DEFAULT 0,                                -- 学期ID
+  `eExam`        INTEGER NOT NULL DEFAULT 0,                                -- 考试编号
+  `iSubjectID`   INTEGER NOT NULL DEFAULT 0,                                -- 课程ID
+  `iScore`       INTEGER NOT NULL DEFAULT 0,                                -- 分数
+  `dtCreateTime` TEXT    NOT NULL DEFAULT (datetime('now', 'localtime')),   -- 创建时间
+  `dtModifyTime` TEXT    NOT NULL DEFAULT (datetime('now', 'localtime')),   -- 修改时间
+  PRIMARY KEY (`iStudentID`, `iTermID`, `eExam`, `iSubjectID`)
+);
+
+CREATE TRIGGER IF NOT EXISTS `trgtbStudentScoreModifyTime` AFTER UPDATE ON `tbStudentScore` FOR EACH ROW
+BEGIN
+  UPDATE `tbStudentScore` SET `dtModifyTime` = datetime('now', 'localtime') WHERE `rowid` = OLD.`rowid`;
+END;
+
+CREATE TABLE IF NOT EXISTS `tbTeacherScore` (
+  `iTeacherScoreID` INTEGER PRIMARY KEY AUTOINCREMENT,                         -- 主键
+  `iTeacherID`      INTEGER NOT NULL DEFAULT 0,                                -- 教师表主键
+  `iScore`          INTEGER NOT NULL DEFAULT 0,                                -- 分数
+  `eStatus`         INTEGER NOT NULL DEFAULT 1,                                -- 逻辑状态
+  `dtCreateTime`    TEXT    NOT NULL DEFAULT (datetime('now', 'localtime')),   -- 创建时间
+  `dtModifyTime`    TEXT    NOT NULL DEFAULT (datetime('now', 'localtime'))    -- 修改时间
+);
+
+CREATE TRIGGER IF NOT EXISTS `trgtbTeacherScoreModifyTime` AFTER UPDATE ON `tbTeacherScore` FOR EACH ROW
+BEGIN
+  UPDATE `tbTeacherScore` SET `dtModifyTime` = datetime('now', 'localtime') WHERE `iTeacherScoreID` = OLD.`iTeacherScoreID`;
+END;
+
+CREATE TABLE IF NOT EXISTS `tbTerm` (
+  `iTermID`     INTEGER PRIMARY KEY AUTOINCREMENT,      -- 主键
+  `iSchoolYear` INTEGER NOT NULL DEFAULT 0,             -- 学年
+  `eTerm`       INTEGER NOT NULL DEFAULT 0,             -- 学期
+  `dtBegin`     TEXT    NOT NULL DEFAULT '0000-00-00',  -- 学期开始日期
+  `dtEnd`       TEXT    NOT NULL DEFAULT '0000-00-00'   -- 学期结束日期
+);
+
+CREATE TABLE IF NOT EXISTS `tbQuestionnaire` (
+  `iQuestionnaireID` INTEGER PRIMARY KEY AUTOINCREMENT,               -- 问卷id
+  `vTitle`           TEXT    NOT NULL DEFAULT '',                     -- 问卷标题
+  `eDraftStatus`     INTEGER NOT NULL DEFAULT 0,                      -- 文稿状态, 1: 草稿, 2: 已发布, 3: 已撤回, 4: 已过期, 5: 待发布
+  `dtStartTime`      TEXT    NOT NULL DEFAULT '0000-00-00 00:00:00',  -- 开发日期
+  `dtStopTime`       TEXT    NOT NULL DEFAULT '0000-00-00 00:00:00',  -- 截至日期
+  `vEditorName`      TEXT    NOT NULL DEFAULT ''                      -- 编辑
+);
+
+CREATE TABLE IF NOT EXISTS `tbQuestion` (
+  `iQuestionID`      INTEGER PRIMARY KEY AUTOINCREMENT, -- 问卷id
+  `iQuestionnaireID` INTEGER NOT NULL DEFAULT 0,        -- 问卷id
+  `iIndex`           INTEGER NOT NULL DEFAULT 0,        -- 问题编号
+  `eType`            INTEGER NOT NULL DEFAULT 0,        -- 问题类型, 1: 单选, 2: 多选, 3: 文本
+  `bRequired`        INTEGER NOT NULL DEFAULT 0,        -- 是否为必填项
+  `vQuestion`        TEXT    NOT NULL,                  -- 问卷标题, base64编码的文本
+  `vContent`         TEXT    NOT NULL                   -- 题目内容, base64编码的json字符串
+);
+
+CREATE TABLE IF NOT EXISTS `tbOption` (
+  `iOptionID`   INTEGER PRIMARY KEY AUTOINCREMENT, -- 问卷id
+  `iQuestionID` INTEGER NOT NULL DEFAULT 0,        -- 问卷id
+  `vOption`     TEXT    NOT NULL DEFAULT '',       -- 问卷标题
+  `iIndex`      INTEGER NOT NULL DEFAULT 0         -- 问题编号
+);
+
+CREATE TABLE IF NOT EXISTS `tbSubmission` (
+  `iSubmissionID`    INTEGER PRIMARY KEY AUTOINCREMENT,                         -- 主键
+  `iQuestionnaireID` INTEGER NOT NULL DEFAULT 0,                                -- 问卷id
+  `iStudentID`       INTEGER NOT NULL DEFAULT 0,                                -- 学生ID
+  `iTeacherID`       INTEGER NOT NULL DEFAULT 0,                                -- 教师ID
+  `iGrade`           INTEGER NOT NULL DEFAULT 0,                                -- 提交时所在年级
+  `iIndex`           INTEGER NOT NULL DEFAULT 0,                                -- 提交时所在班级
+  `vContent`         TEXT    NOT NULL,                                          -- 答案内容, base64编码的json字符串
+  `eStatus`          INTEGER NOT NULL DEFAULT 1,                                -- 逻辑状态
+  `dtCreateTime`     TEXT    NOT NULL DEFAULT (datetime('now', 'localtime')),   -- 创建时间
+  `dtModifyTime`     TEXT    NOT NULL DEFAULT (datetime('now', 'localtime'))    -- 修改时间
+);
+
+CREATE INDEX IF NOT EXISTS `idx_questionnaire_student` ON `tbSubmission` (`iQuestionnaireID`, `iStudentID`);
+
+CREATE TRIGGER IF NOT EXISTS `trgtbSubmissionModifyTime` AFTER UPDATE ON `tbSubmission` FOR EACH ROW
+BEGIN
+  UPDATE `tbSubmission` SET `dtModifyTime` = datetime('now', 'localtime') WHERE `iSubmissionID` = OLD.`iSubmissionID`;
+END;
+
+-- sqlite only auto increments a single column primary key,
+-- the mysql key (iVoteID, vVoteCode) is kept as a unique index
+CREATE TABLE IF NOT EXISTS `tbVote` (
+  `iVoteID`      INTEGER PRIMARY KEY AUTOINCREMENT,                         -- 主键
+  `vVoteCode`    TEXT    NOT NULL DEFAULT '',                               -- 投票码
+  `vVoteDetail`  TEXT    NOT NULL DEFAULT '',                               -- 投票详情
+  `dtCreateTime` TEXT    NOT NULL DEFAULT (datetime('now', 'localtime')),   -- 创建时间
+  `dtModifyTime` TEXT    NOT NULL DEFAULT (datetime('now', 'localtime'))    -- 修改时间
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_vote_code` ON `tbVote` (`iVoteID`, `vVoteCode`);
+
+CREATE TRIGGER IF NOT EXISTS `trgtbVoteModifyTime` AFTER UPDATE ON `tbVote` FOR EACH ROW
+BEGIN
+  UPDATE `tbVote` SET `dtModifyTime` = datetime('now', 'localtime') WHERE `iVoteID` = OLD.`iVoteID`;
+END;
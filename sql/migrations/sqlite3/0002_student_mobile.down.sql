@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS `idx_class`;
+
+ALTER TABLE `tbStudent` DROP COLUMN `vMobile`;
@@ -0,0 +1,4 @@
+-- iClassID is already an INTEGER column in sqlite
+ALTER TABLE `tbStudent` ADD COLUMN `vMobile` TEXT NOT NULL DEFAULT ''; -- 手机号
+
+CREATE INDEX IF NOT EXISTS `idx_class` ON `tbClassTeacherRelation` (`iClassID`);
@@ -0,0 +1 @@
+ALTER TABLE `tbPassword` DROP COLUMN `iRole`;
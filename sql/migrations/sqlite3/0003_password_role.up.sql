@@ -0,0 +1 @@
+ALTER TABLE `tbPassword` ADD COLUMN `iRole` INTEGER NOT NULL DEFAULT 0; -- 角色, 按位组合: 1 系统管理员, 2 教务处, 4 班主任, 8 任课教师, 16 学生
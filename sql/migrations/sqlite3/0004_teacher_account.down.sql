@@ -0,0 +1,2 @@
+ALTER TABLE `tbPassword` DROP COLUMN `bMustChange`;
+ALTER TABLE `tbPassword` DROP COLUMN `eStatus`;
@@ -0,0 +1,2 @@
+ALTER TABLE `tbPassword` ADD COLUMN `eStatus` INTEGER NOT NULL DEFAULT 1 CHECK (`eStatus` IN (1, 2)); -- 账号状态: 1 正常, 2 停用
+ALTER TABLE `tbPassword` ADD COLUMN `bMustChange` INTEGER NOT NULL DEFAULT 0; -- 下次登录须修改密码
@@ -0,0 +1 @@
+ALTER TABLE `tbQuestionnaire` ADD COLUMN `bAllowAmend` INTEGER NOT NULL DEFAULT 0; -- 截至日期前是否允许修改答案
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS `uk_submission`;
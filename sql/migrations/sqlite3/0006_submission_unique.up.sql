@@ -0,0 +1,3 @@
+DELETE FROM `tbSubmission` WHERE `iSubmissionID` NOT IN
+  (SELECT MIN(`iSubmissionID`) FROM `tbSubmission` GROUP BY `iQuestionnaireID`, `iStudentID`, `iTeacherID`, `eStatus`);
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_submission` ON `tbSubmission` (`iQuestionnaireID`, `iStudentID`, `iTeacherID`, `eStatus`);
@@ -29,7 +29,8 @@ func login(host, name, password string) (string, error) {
 
 	// set global token
 	ret := struct {
-		Token string `json:"token"`
+		Token  string `json:"token"`
+		Secret string `json:"secret"`
 	}{}
 	err = json.Unmarshal([]byte(buff), &ret)
 	if err != nil {
@@ -37,6 +38,7 @@ func login(host, name, password string) (string, error) {
 		return "", err
 	}
 	token = ret.Token
+	secret = ret.Secret
 
 	return token, nil
 }
@@ -1,31 +1,47 @@
 package main
 
 import (
-	"crypto/md5"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/arong/dean/base"
+	"github.com/google/uuid"
 )
 
 var token string
+var secret string
 
 func sendPostRequest(URL string, data interface{}) (json.RawMessage, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
 	req := struct {
-		Token     string      `json:"token"`
-		Timestamp int64       `json:"timestamp"`
-		Data      interface{} `json:"data"`
-		Check     string      `json:"check"`
+		Token     string          `json:"token"`
+		Timestamp int64           `json:"timestamp"`
+		Nonce     string          `json:"nonce"`
+		Data      json.RawMessage `json:"data"`
+		Check     string          `json:"check"`
 	}{
 		Token:     token,
 		Timestamp: time.Now().Unix(),
-		Data:      data,
+		Nonce:     uuid.New().String(),
+		Data:      body,
 	}
-	req.Check = fmt.Sprintf("%x", md5.Sum([]byte(req.Token+strconv.FormatInt(req.Timestamp, 10))))
+
+	u, err := url.Parse(URL)
+	if err != nil {
+		return nil, err
+	}
+	req.Check = base.Sign(secret, "POST", u.Path, req.Timestamp, req.Nonce, body)
 	buff, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
@@ -74,24 +90,17 @@ func sendPostRequest(URL string, data interface{}) (json.RawMessage, error) {
 }
 
 func sendGetRequest(URL string, data interface{}) ([]byte, error) {
-	req := struct {
-		Token     string `json:"token"`
-		Timestamp int64  `json:"timestamp"`
-		Check     string `json:"check"`
-	}{
-		Token:     token,
-		Timestamp: time.Now().Unix(),
-	}
-	req.Check = fmt.Sprintf("%x", md5.Sum([]byte(req.Token+strconv.FormatInt(req.Timestamp, 10))))
-
 	request, err := http.NewRequest("GET", URL, nil)
 	if err != nil {
 		return nil, err
 	}
+	timestamp := time.Now().Unix()
+	nonce := uuid.New().String()
 	q := request.URL.Query()
-	q.Set("token", req.Token)
-	q.Set("timestamp", strconv.FormatInt(req.Timestamp, 10))
-	q.Set("check", req.Check)
+	q.Set("token", token)
+	q.Set("timestamp", strconv.FormatInt(timestamp, 10))
+	q.Set("nonce", nonce)
+	q.Set("check", base.Sign(secret, "GET", request.URL.Path, timestamp, nonce, base.CanonicalQuery(q)))
 	request.URL.RawQuery = q.Encode()
 
 	client := http.Client{}